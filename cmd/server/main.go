@@ -4,16 +4,176 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/clientip"
+	"github.com/franciscodelahoz/load-balancer/internal/concurrency"
 	"github.com/franciscodelahoz/load-balancer/internal/config"
+	"github.com/franciscodelahoz/load-balancer/internal/discovery"
 	"github.com/franciscodelahoz/load-balancer/internal/handlers"
+	"github.com/franciscodelahoz/load-balancer/internal/hedge"
+	"github.com/franciscodelahoz/load-balancer/internal/listeners"
 	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+	"github.com/franciscodelahoz/load-balancer/internal/mirror"
+	"github.com/franciscodelahoz/load-balancer/internal/proxyproto"
+	"github.com/franciscodelahoz/load-balancer/internal/ratelimit"
+	"github.com/franciscodelahoz/load-balancer/internal/router"
+	"github.com/franciscodelahoz/load-balancer/internal/split"
 	"github.com/franciscodelahoz/load-balancer/internal/strategies"
 )
 
+func newBackend(poolConfig config.PoolConfig, backendConfig config.BackendConfig) (*backend.Backend, error) {
+	backendURL, err := url.Parse(backendConfig.URL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newBackend := backend.CreateBackendInstance(*backendURL, backendConfig.Weight, 1)
+
+	if backendConfig.ProxyProtocol != "" {
+		newBackend.SetProxyProtocol(backendConfig.ProxyProtocol)
+	}
+
+	newBackend.Priority = backendConfig.Priority
+	newBackend.HealthCheck = backendConfig.GetHealthCheckOverride()
+	newBackend.SetLabels(backendConfig.Labels)
+
+	if adaptive := poolConfig.LoadBalancer.AdaptiveConcurrency; adaptive != nil {
+		newBackend.ConcurrencyLimiter = concurrency.NewLimiter(adaptive.GetConcurrencyConfig())
+	}
+
+	return newBackend, nil
+}
+
+// newDiscoveredBackend creates the backend of a discovered target, using base
+// for every setting the target does not provide.
+func newDiscoveredBackend(poolConfig config.PoolConfig, base config.BackendConfig, target discovery.Target) (*backend.Backend, error) {
+	backendConfig := base
+	backendConfig.URL = target.URL
+	backendConfig.Weight = target.Weight
+	backendConfig.Priority = target.Priority
+
+	discoveredBackend, err := newBackend(poolConfig, backendConfig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	discoveredBackend.SetLabels(target.Labels)
+
+	if target.HealthCheck != nil {
+		discoveredBackend.HealthCheck = target.HealthCheck
+	}
+
+	return discoveredBackend, nil
+}
+
+// startDiscovery keeps the backends of a provider in the pool. Settings the
+// provider does not report are taken from base.
+func startDiscovery(poolConfig config.PoolConfig, base config.BackendConfig, provider discovery.Provider, loadBalancer *loadbalancer.LoadBalancer) {
+	syncer := discovery.NewSyncer(provider.Name(), loadBalancer, func(target discovery.Target) (*backend.Backend, error) {
+		return newDiscoveredBackend(poolConfig, base, target)
+	})
+
+	discovery.Start(provider, syncer)
+
+	log.Printf("🔎 Discovery enabled for pool %s: %s", poolConfig.Name, provider.Name())
+}
+
+func buildLoadBalancer(poolConfig config.PoolConfig) (*loadbalancer.LoadBalancer, error) {
+	strategyFactory := strategies.NewStrategyFactory()
+	strategy, err := strategyFactory.CreateLoadbalancerStrategy(poolConfig.LoadBalancer.Strategy)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating strategy '%s': %w", poolConfig.LoadBalancer.Strategy, err)
+	}
+
+	if zoneAware := poolConfig.LoadBalancer.ZoneAware; zoneAware != nil {
+		strategy = strategies.NewZoneAwareStrategy(strategy, zoneAware.GetZoneAwareConfig())
+	}
+
+	// Priority levels are chosen before zones, as zone awareness applies
+	// within the active level
+	if poolConfig.UsesPriorities() {
+		strategy = strategies.NewPriorityStrategy(strategy, poolConfig.GetOverprovisioningFactor())
+	}
+
+	loadBalancer := loadbalancer.NewLoadBalancer(strategy)
+
+	// Health checking starts before any backend is added, so every backend,
+	// static or discovered, gets its initial state before it is in rotation
+	if poolConfig.HealthCheck.IsEnabled() {
+		healthConfig := poolConfig.HealthCheck.GetHealthConfig()
+		loadBalancer.StartHealthChecking(*healthConfig)
+
+		log.Printf("🏥 Health checking enabled for pool %s (interval: %v)", poolConfig.Name, poolConfig.HealthCheck.Interval)
+	}
+
+	for _, backendConfig := range poolConfig.Backends {
+		if backendConfig.Discovery != "" {
+			dnsConfig, err := backendConfig.GetDNSConfig()
+
+			if err != nil {
+				log.Printf("❌ Invalid backend URL %s: %v", backendConfig.URL, err)
+				continue
+			}
+
+			startDiscovery(poolConfig, backendConfig, discovery.NewDNSProvider(dnsConfig, nil), loadBalancer)
+			continue
+		}
+
+		backend, err := newBackend(poolConfig, backendConfig)
+
+		if err != nil {
+			log.Printf("❌ Invalid backend URL %s: %v", backendConfig.URL, err)
+			continue
+		}
+
+		loadBalancer.AddBackend(backend)
+
+		log.Printf("✅ Added backend to pool %s: %s (weight: %d)", poolConfig.Name, backendConfig.URL, backendConfig.Weight)
+	}
+
+	for _, discoveryConfig := range poolConfig.Discovery {
+		startDiscovery(poolConfig, config.BackendConfig{}, discoveryConfig.GetProvider(), loadBalancer)
+	}
+
+	return loadBalancer, nil
+}
+
+func withRateLimiter(handler http.Handler, limiter *ratelimit.Limiter) http.Handler {
+	if limiter == nil {
+		return handler
+	}
+
+	return limiter.Middleware(handler)
+}
+
+func withMirror(handler http.Handler, requestMirror *mirror.Mirror) http.Handler {
+	if requestMirror == nil {
+		return handler
+	}
+
+	return requestMirror.Middleware(handler)
+}
+
+// startAdminServer serves the admin API on its own listener, which only
+// accepts local connections unless admin.address says otherwise.
+func startAdminServer(cfg *config.Config, adminHandler *handlers.AdminHandler) {
+	adminAddress := net.JoinHostPort(cfg.Admin.Address, strconv.Itoa(cfg.Admin.Port))
+
+	go func() {
+		log.Fatal(http.ListenAndServe(adminAddress, adminHandler))
+	}()
+
+	log.Printf("🏢 Admin API: http://%s/admin/health", adminAddress)
+}
+
 func main() {
 	log.Println("🚀 Starting Load Balancer...")
 
@@ -27,42 +187,243 @@ func main() {
 		return
 	}
 
-	strategyFactory := strategies.NewStrategyFactory()
-	strategy, err := strategyFactory.CreateLoadbalancerStrategy(cfg.LoadBalancer.Strategy)
+	loadBalancers := make(map[string]*loadbalancer.LoadBalancer)
+	requiredPools := make(map[string]bool)
+
+	for _, poolConfig := range cfg.GetPools() {
+		loadBalancer, err := buildLoadBalancer(poolConfig)
+
+		if err != nil {
+			log.Fatalf("❌ Error creating pool '%s': %v", poolConfig.Name, err)
+		}
+
+		loadBalancers[poolConfig.Name] = loadBalancer
+
+		// The implicit default pool may stay empty when every request is
+		// routed to a named pool
+		requiredPools[poolConfig.Name] = poolConfig.Name != config.DefaultPoolName || poolConfig.HasBackendSources()
+
+		log.Printf("📊 Pool %s strategy: %s", poolConfig.Name, loadBalancer.GetStrategyName())
+	}
+
+	clientIPResolver, err := clientip.NewResolver(cfg.Forwarding.TrustedProxies)
 
 	if err != nil {
-		log.Fatalf("❌ Error creating strategy '%s': %v", cfg.LoadBalancer.Strategy, err)
+		log.Fatalf("❌ Error creating client IP resolver: %v", err)
 	}
 
-	loadBalancer := loadbalancer.NewLoadBalancer(strategy)
+	globalHeaderRules := cfg.Headers.GetRules()
+	rateLimiters := make(map[string]*ratelimit.Limiter)
+
+	var globalRateLimiter *ratelimit.Limiter
+
+	if cfg.RateLimit != nil {
+		globalRateLimiter = ratelimit.NewLimiter(cfg.RateLimit.GetRateLimitConfig())
+		rateLimiters["global"] = globalRateLimiter
+
+		log.Printf("🚦 Rate limiting enabled (rate: %v/s, burst: %d, key: %s)", cfg.RateLimit.Rate, cfg.RateLimit.Burst, cfg.RateLimit.Key)
+	}
+
+	mirrors := make(map[string]*mirror.Mirror)
+
+	var globalMirror *mirror.Mirror
+
+	if cfg.Mirror != nil {
+		globalMirror = mirror.NewMirror(cfg.Mirror.GetMirrorConfig(), loadBalancers[cfg.Mirror.Pool])
+		mirrors["global"] = globalMirror
+
+		log.Printf("🪞 Mirroring %v%% of requests to pool %s", cfg.Mirror.Percent, cfg.Mirror.Pool)
+	}
+
+	hedgingPolicies := make(map[string]*hedge.Policy)
+
+	var globalHedging *hedge.Policy
+
+	if cfg.Hedging != nil {
+		globalHedging = hedge.NewPolicy(cfg.Hedging.GetHedgingConfig())
+		hedgingPolicies["global"] = globalHedging
+
+		snapshot := globalHedging.Snapshot()
+		log.Printf("🏇 Request hedging enabled (delay: %s, percentile: %v, max ratio: %v)", snapshot.Delay, cfg.Hedging.Percentile, snapshot.MaxRatio)
+	}
+
+	defaultHandler := handlers.NewProxyHandler(loadBalancers[config.DefaultPoolName])
+	defaultHandler.SetHeaderRules(globalHeaderRules)
+	defaultHandler.SetForwardedHeader(cfg.Forwarding.ForwardedHeader)
+	defaultHandler.SetHedging(globalHedging)
+
+	requestRouter := router.NewRouter(withRateLimiter(withMirror(defaultHandler, globalMirror), globalRateLimiter))
+	splits := make(map[string]*split.Split)
 
-	for _, backendConfig := range cfg.Backends {
-		backendURL, err := url.Parse(backendConfig.URL)
+	for _, routeConfig := range cfg.Routes {
+		match, err := routeConfig.GetMatch()
 
 		if err != nil {
-			log.Printf("❌ Invalid backend URL %s: %v", backendConfig.URL, err)
-			continue
+			log.Fatalf("❌ Error creating route '%s': %v", routeConfig.Name, err)
 		}
 
-		backend := backend.CreateBackendInstance(*backendURL, backendConfig.Weight, 1)
-		loadBalancer.AddBackend(backend)
+		routeHedging := globalHedging
+
+		if routeConfig.Hedging != nil {
+			routeHedging = hedge.NewPolicy(routeConfig.Hedging.GetHedgingConfig())
+			hedgingPolicies["route:"+routeConfig.Name] = routeHedging
+		}
+
+		newRouteHandler := func(pool string) http.Handler {
+			proxyHandler := handlers.NewProxyHandler(loadBalancers[pool])
+			proxyHandler.SetHeaderRules(globalHeaderRules, routeConfig.Headers.GetRules())
+			proxyHandler.SetForwardedHeader(cfg.Forwarding.ForwardedHeader)
+			proxyHandler.SetHedging(routeHedging)
+
+			return proxyHandler
+		}
+
+		var routeHandler http.Handler
+
+		if routeConfig.Split != nil {
+			poolHandlers := make(map[string]http.Handler)
+
+			for _, destination := range routeConfig.Split.Pools {
+				poolHandlers[destination.Pool] = newRouteHandler(destination.Pool)
+			}
+
+			routeSplit, err := split.NewSplit(routeConfig.GetSplitConfig(), poolHandlers)
+
+			if err != nil {
+				log.Fatalf("❌ Error creating route '%s': %v", routeConfig.Name, err)
+			}
+
+			splits[routeConfig.Name] = routeSplit
+			routeHandler = routeSplit
+		} else {
+			routeHandler = newRouteHandler(routeConfig.Pool)
+		}
+
+		routeRateLimiter := globalRateLimiter
 
-		log.Printf("✅ Added backend: %s (weight: %d)", backendConfig.URL, backendConfig.Weight)
+		if routeConfig.RateLimit != nil {
+			routeRateLimiter = ratelimit.NewLimiter(routeConfig.RateLimit.GetRateLimitConfig())
+			rateLimiters["route:"+routeConfig.Name] = routeRateLimiter
+		}
+
+		routeMirror := globalMirror
+
+		if routeConfig.Mirror != nil {
+			routeMirror = mirror.NewMirror(routeConfig.Mirror.GetMirrorConfig(), loadBalancers[routeConfig.Mirror.Pool])
+			mirrors["route:"+routeConfig.Name] = routeMirror
+
+			log.Printf("🪞 Mirroring %v%% of route %s to pool %s", routeConfig.Mirror.Percent, routeConfig.Name, routeConfig.Mirror.Pool)
+		}
+
+		requestRouter.AddRoute(routeConfig.Name, match, withRateLimiter(withMirror(routeHandler, routeMirror), routeRateLimiter))
+
+		if routeConfig.Split != nil {
+			log.Printf("🧭 Added route %s -> split %v", routeConfig.Name, routeConfig.Split.Pools)
+		} else {
+			log.Printf("🧭 Added route %s -> pool %s", routeConfig.Name, routeConfig.Pool)
+		}
 	}
 
-	if cfg.IsHealthCheckEnabled() {
-		healthConfig := cfg.GetHealthConfig()
-		loadBalancer.StartHealthChecking(*healthConfig)
+	listenerStats := make(map[string]func() any)
+
+	for _, listenerConfig := range cfg.Listeners {
+		var listener interface{ ListenAndServe() error }
+
+		switch listenerConfig.Mode {
+		case listeners.ModeTLSPassthrough:
+			sniRouter := listeners.NewSNIRouter(loadBalancers[listenerConfig.Pool])
+
+			for serverName, pool := range listenerConfig.SNI {
+				sniRouter.AddRoute(serverName, loadBalancers[pool])
+			}
+
+			listener = listeners.NewTLSPassthroughProxy(listenerConfig.GetListenerConfig(), sniRouter)
+		case listeners.ModeUDP:
+			udpProxy := listeners.NewUDPProxy(listenerConfig.GetListenerConfig(), loadBalancers[listenerConfig.Pool])
+			listenerStats[listenerConfig.Name] = func() any { return udpProxy.GetStats() }
+			listener = udpProxy
+		default:
+			listener = listeners.NewTCPProxy(listenerConfig.GetListenerConfig(), loadBalancers[listenerConfig.Pool])
+		}
+
+		go func() {
+			if err := listener.ListenAndServe(); err != nil {
+				log.Fatalf("❌ Error running listener '%s': %v", listenerConfig.Name, err)
+			}
+		}()
+
+		log.Printf("🔌 Added %s listener %s on port %d -> pool %s", listenerConfig.Mode, listenerConfig.Name, listenerConfig.Port, listenerConfig.Pool)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", clientIPResolver.Middleware(requestRouter))
+
+	if cfg.Readiness != nil {
+		mux.Handle(cfg.Readiness.Path, handlers.NewReadinessHandler(loadBalancers, requiredPools, cfg.Readiness.MinHealthyBackends))
+		log.Printf("🚦 Readiness endpoint: http://localhost:%d%s (min healthy backends: %d)", cfg.Server.Port, cfg.Readiness.Path, cfg.Readiness.MinHealthyBackends)
+	}
+
+	if cfg.IsAdminEnabled() {
+		adminHandler := handlers.NewAdminHandler()
+		adminHandler.RegisterLoadBalancers(loadBalancers)
+		adminHandler.RegisterSplits(splits)
+		adminHandler.HandleJSON("/admin/ratelimit", func() any {
+			snapshots := make(map[string]*ratelimit.Snapshot, len(rateLimiters))
+
+			for name, limiter := range rateLimiters {
+				snapshots[name] = limiter.Snapshot()
+			}
+
+			return snapshots
+		})
+
+		adminHandler.HandleJSON("/admin/mirrors", func() any {
+			snapshots := make(map[string]*mirror.Snapshot, len(mirrors))
+
+			for name, requestMirror := range mirrors {
+				snapshots[name] = requestMirror.Snapshot()
+			}
+
+			return snapshots
+		})
+
+		adminHandler.HandleJSON("/admin/hedging", func() any {
+			snapshots := make(map[string]*hedge.Snapshot, len(hedgingPolicies))
+
+			for name, policy := range hedgingPolicies {
+				snapshots[name] = policy.Snapshot()
+			}
+
+			return snapshots
+		})
 
-		log.Printf("🏥 Health checking enabled (interval: %v)", cfg.HealthCheck.Interval)
+		adminHandler.HandleJSON("/admin/stats/listeners", func() any {
+			stats := make(map[string]any, len(listenerStats))
+
+			for name, provider := range listenerStats {
+				stats[name] = provider()
+			}
+
+			return stats
+		})
+
+		startAdminServer(cfg, adminHandler)
 	}
 
 	address := fmt.Sprintf(":%d", cfg.Server.Port)
-	proxyHandler := handlers.NewProxyHandler(loadBalancer)
 
 	log.Printf("🚀 Load Balancer running on :%s", address)
-	log.Printf("📊 Strategy: %s", loadBalancer.GetStrategyName())
-	log.Printf("🏢 Admin API: http://localhost:%s/admin/health", address)
 
-	log.Fatal(http.ListenAndServe(address, proxyHandler))
+	listener, err := net.Listen("tcp", address)
+
+	if err != nil {
+		log.Fatalf("❌ Error listening on %s: %v", address, err)
+	}
+
+	if cfg.Server.ProxyProtocol {
+		listener = proxyproto.NewListener(listener, cfg.Server.ProxyProtocolReadTimeout)
+		log.Printf("🔌 PROXY protocol enabled on %s", address)
+	}
+
+	log.Fatal(http.Serve(listener, mux))
 }
@@ -6,8 +6,23 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/concurrency"
+	"github.com/franciscodelahoz/load-balancer/internal/proxyproto"
 )
 
+// HealthCheckOverride holds the health check settings a backend overrides
+// from its pool. Zero values keep the pool setting.
+type HealthCheckOverride struct {
+	Path             string
+	Port             int
+	Method           string
+	Interval         time.Duration
+	Timeout          time.Duration
+	SuccessThreshold int
+	FailureThreshold int
+}
+
 type Backend struct {
 	URL                *url.URL
 	Alive              bool
@@ -17,7 +32,12 @@ type Backend struct {
 	LastErrorTime      time.Time
 	ResponseTimeSum    time.Duration
 	Weight             uint64
+	Priority           int
 	MaxConnections     uint64
+	ProxyProtocol      string
+	ConcurrencyLimiter *concurrency.Limiter
+	HealthCheck        *HealthCheckOverride
+	labels             map[string]string
 	activeConnections  uint64
 	mutex              sync.RWMutex
 	consecutiveErrors  int
@@ -34,6 +54,34 @@ func CreateBackendInstance(url url.URL, weight uint64, maxConnections uint64) *B
 	}
 }
 
+func (b *Backend) SetProxyProtocol(version string) {
+	b.ProxyProtocol = version
+	b.ReverseProxy.Transport = proxyproto.NewTransport(version)
+}
+
+// SetLabels replaces the labels of the backend, such as its zone or version.
+func (b *Backend) SetLabels(labels map[string]string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.labels = labels
+}
+
+// GetLabels returns the labels of the backend. The map must not be modified.
+func (b *Backend) GetLabels() map[string]string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.labels
+}
+
+func (b *Backend) GetLabel(name string) string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.labels[name]
+}
+
 func (b *Backend) SetHealth(healthy bool) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
@@ -81,6 +129,10 @@ func (b *Backend) GetWeight() uint64 {
 	return atomic.LoadUint64(&b.Weight)
 }
 
+func (b *Backend) SetWeight(weight uint64) {
+	atomic.StoreUint64(&b.Weight, weight)
+}
+
 func (b *Backend) IncreaseConsecutiveErrors() {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
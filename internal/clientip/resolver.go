@@ -0,0 +1,145 @@
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+type Info struct {
+	ClientIP    string
+	PeerIP      string
+	TrustedPeer bool
+}
+
+type Resolver struct {
+	trustedProxies []*net.IPNet
+}
+
+func NewResolver(trustedProxies []string) (*Resolver, error) {
+	resolver := &Resolver{
+		trustedProxies: make([]*net.IPNet, 0, len(trustedProxies)),
+	}
+
+	for _, entry := range trustedProxies {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: %s", entry)
+			}
+
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR %s: %w", entry, err)
+		}
+
+		resolver.trustedProxies = append(resolver.trustedProxies, network)
+	}
+
+	return resolver, nil
+}
+
+func (res *Resolver) IsTrusted(address string) bool {
+	ip := net.ParseIP(address)
+
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range res.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// Resolve walks X-Forwarded-For from right to left, skipping trusted proxies,
+// and only when the direct peer is itself a trusted proxy. Headers sent by
+// untrusted peers are ignored, so clients cannot spoof their address.
+func (res *Resolver) Resolve(r *http.Request) *Info {
+	peer := peerIP(r)
+
+	info := &Info{
+		ClientIP:    peer,
+		PeerIP:      peer,
+		TrustedPeer: res.IsTrusted(peer),
+	}
+
+	if !info.TrustedPeer {
+		return info
+	}
+
+	var hops []string
+
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for hop := range strings.SplitSeq(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i -= 1 {
+		if net.ParseIP(hops[i]) == nil {
+			break
+		}
+
+		info.ClientIP = hops[i]
+
+		if !res.IsTrusted(hops[i]) {
+			break
+		}
+	}
+
+	return info
+}
+
+func (res *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), contextKey{}, res.Resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func FromRequest(r *http.Request) *Info {
+	if info, ok := r.Context().Value(contextKey{}).(*Info); ok {
+		return info
+	}
+
+	peer := peerIP(r)
+
+	return &Info{
+		ClientIP: peer,
+		PeerIP:   peer,
+	}
+}
+
+func ClientIP(r *http.Request) string {
+	return FromRequest(r).ClientIP
+}
@@ -0,0 +1,202 @@
+package concurrency
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	AlgorithmAIMD     = "aimd"
+	AlgorithmGradient = "gradient"
+
+	DefaultInitialLimit = 20
+	DefaultMinLimit     = 1
+	DefaultMaxLimit     = 1000
+	DefaultBackoffRatio = 0.9
+	DefaultTolerance    = 2.0
+	DefaultSmoothing    = 0.2
+
+	// Weight of a new sample in the long-term latency average, roughly the
+	// last 600 samples
+	longRTTWeight = 1.0 / 600
+)
+
+type Config struct {
+	Algorithm        string
+	InitialLimit     int
+	MinLimit         int
+	MaxLimit         int
+	BackoffRatio     float64
+	LatencyThreshold time.Duration
+	Tolerance        float64
+	Smoothing        float64
+}
+
+// Limiter bounds the requests in flight to a single backend and adapts the
+// bound to the observed latency, in the spirit of Netflix concurrency-limits.
+//
+// AIMD grows the limit by one for every successful sample taken while the
+// limit is in use and multiplies it by BackoffRatio on errors or when latency
+// exceeds LatencyThreshold (or Tolerance times the long-term average).
+//
+// Gradient scales the limit by the ratio between the long-term and the current
+// latency, plus a small queue allowance, so the limit shrinks as soon as the
+// backend starts queueing.
+type Limiter struct {
+	config   *Config
+	limit    float64
+	inFlight int
+	longRTT  float64
+	mutex    sync.Mutex
+}
+
+type Snapshot struct {
+	Algorithm string  `json:"algorithm"`
+	Limit     int     `json:"limit"`
+	InFlight  int     `json:"in_flight"`
+	LongRTTMs float64 `json:"long_rtt_ms"`
+}
+
+func (c *Config) applyDefaults() {
+	if c.Algorithm == "" {
+		c.Algorithm = AlgorithmAIMD
+	}
+
+	if c.MinLimit <= 0 {
+		c.MinLimit = DefaultMinLimit
+	}
+
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = DefaultMaxLimit
+	}
+
+	if c.InitialLimit <= 0 {
+		c.InitialLimit = DefaultInitialLimit
+	}
+
+	c.InitialLimit = min(max(c.InitialLimit, c.MinLimit), c.MaxLimit)
+
+	if c.BackoffRatio <= 0 || c.BackoffRatio >= 1 {
+		c.BackoffRatio = DefaultBackoffRatio
+	}
+
+	if c.Tolerance <= 0 {
+		c.Tolerance = DefaultTolerance
+	}
+
+	if c.Smoothing <= 0 || c.Smoothing > 1 {
+		c.Smoothing = DefaultSmoothing
+	}
+}
+
+func ValidateAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", AlgorithmAIMD, AlgorithmGradient:
+		return nil
+	default:
+		return fmt.Errorf("unknown adaptive concurrency algorithm: %s", algorithm)
+	}
+}
+
+func NewLimiter(config Config) *Limiter {
+	config.applyDefaults()
+
+	return &Limiter{
+		config: &config,
+		limit:  float64(config.InitialLimit),
+	}
+}
+
+func (l *Limiter) TryAcquire() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.inFlight >= int(l.limit) {
+		return false
+	}
+
+	l.inFlight += 1
+	return true
+}
+
+func (l *Limiter) Release(latency time.Duration, failed bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	inFlight := l.inFlight
+	l.inFlight -= 1
+
+	rtt := math.Max(1, float64(latency))
+
+	if l.longRTT == 0 {
+		l.longRTT = rtt
+	}
+
+	switch l.config.Algorithm {
+	case AlgorithmGradient:
+		l.updateGradient(rtt, inFlight, failed)
+	default:
+		l.updateAIMD(rtt, inFlight, failed)
+	}
+
+	l.longRTT = l.longRTT*(1-longRTTWeight) + rtt*longRTTWeight
+	l.limit = math.Min(float64(l.config.MaxLimit), math.Max(float64(l.config.MinLimit), l.limit))
+}
+
+func (l *Limiter) isSlow(rtt float64) bool {
+	if l.config.LatencyThreshold > 0 {
+		return rtt > float64(l.config.LatencyThreshold)
+	}
+
+	return rtt > l.longRTT*l.config.Tolerance
+}
+
+func (l *Limiter) updateAIMD(rtt float64, inFlight int, failed bool) {
+	if failed || l.isSlow(rtt) {
+		l.limit *= l.config.BackoffRatio
+		return
+	}
+
+	// Only grow when the current limit is actually being used
+	if float64(inFlight)*2 >= l.limit {
+		l.limit += 1
+	}
+}
+
+func (l *Limiter) updateGradient(rtt float64, inFlight int, failed bool) {
+	if failed {
+		l.limit *= l.config.BackoffRatio
+		return
+	}
+
+	gradient := math.Max(0.5, math.Min(1, l.config.Tolerance*l.longRTT/rtt))
+	newLimit := l.limit * gradient
+
+	// Same as AIMD, the queue allowance is only added while the limit is used
+	if float64(inFlight)*2 >= l.limit {
+		newLimit += math.Sqrt(l.limit)
+	}
+
+	l.limit = l.limit*(1-l.config.Smoothing) + newLimit*l.config.Smoothing
+}
+
+func (l *Limiter) GetLimit() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return int(l.limit)
+}
+
+func (l *Limiter) Snapshot() *Snapshot {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return &Snapshot{
+		Algorithm: l.config.Algorithm,
+		Limit:     int(l.limit),
+		InFlight:  l.inFlight,
+		LongRTTMs: l.longRTT / float64(time.Millisecond),
+	}
+}
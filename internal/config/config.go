@@ -2,12 +2,275 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/clientip"
+	"github.com/franciscodelahoz/load-balancer/internal/concurrency"
+	"github.com/franciscodelahoz/load-balancer/internal/discovery"
+	"github.com/franciscodelahoz/load-balancer/internal/headers"
 	"github.com/franciscodelahoz/load-balancer/internal/health"
+	"github.com/franciscodelahoz/load-balancer/internal/hedge"
+	"github.com/franciscodelahoz/load-balancer/internal/listeners"
+	"github.com/franciscodelahoz/load-balancer/internal/mirror"
+	"github.com/franciscodelahoz/load-balancer/internal/proxyproto"
+	"github.com/franciscodelahoz/load-balancer/internal/ratelimit"
+	"github.com/franciscodelahoz/load-balancer/internal/router"
+	"github.com/franciscodelahoz/load-balancer/internal/split"
+	"github.com/franciscodelahoz/load-balancer/internal/strategies"
 	"gopkg.in/yaml.v3"
 )
 
+func (hc *HealthCheckConfig) applyDefaults(parent HealthCheckConfig) {
+	if hc.Enabled == nil {
+		hc.Enabled = parent.Enabled
+	}
+
+	if hc.Type == "" {
+		hc.Type = parent.Type
+	}
+
+	if hc.Interval == 0 {
+		hc.Interval = parent.Interval
+	}
+
+	if hc.GRPCService == "" {
+		hc.GRPCService = parent.GRPCService
+	}
+
+	if len(hc.Command) == 0 {
+		hc.Command = parent.Command
+	}
+
+	if hc.Timeout == 0 {
+		hc.Timeout = parent.Timeout
+	}
+
+	if hc.Path == "" {
+		hc.Path = parent.Path
+	}
+
+	if hc.Method == "" {
+		hc.Method = parent.Method
+	}
+
+	if hc.SuccessThreshold == 0 {
+		hc.SuccessThreshold = parent.SuccessThreshold
+	}
+
+	if hc.FailureThreshold == 0 {
+		hc.FailureThreshold = parent.FailureThreshold
+	}
+
+	if hc.Jitter == nil {
+		hc.Jitter = parent.Jitter
+	}
+
+	if hc.MaxBackoffInterval == 0 {
+		hc.MaxBackoffInterval = parent.MaxBackoffInterval
+	}
+
+	if hc.MaxConcurrentChecks == 0 {
+		hc.MaxConcurrentChecks = parent.MaxConcurrentChecks
+	}
+
+	if hc.InitialState == "" {
+		hc.InitialState = parent.InitialState
+	}
+
+	if hc.HistorySize == 0 {
+		hc.HistorySize = parent.HistorySize
+	}
+
+	if hc.FlapDetection == nil {
+		hc.FlapDetection = parent.FlapDetection
+	}
+
+	if hc.FlapDetection != nil {
+		if hc.FlapDetection.Window == 0 {
+			hc.FlapDetection.Window = DefaultFlapWindow
+		}
+
+		if hc.FlapDetection.Cooldown == 0 {
+			hc.FlapDetection.Cooldown = DefaultFlapCooldown
+		}
+	}
+
+	if len(hc.Webhooks) == 0 {
+		hc.Webhooks = parent.Webhooks
+	}
+
+	if len(hc.ExpectedStatuses) == 0 {
+		hc.ExpectedStatuses = parent.ExpectedStatuses
+	}
+
+	if hc.BodyContains == "" {
+		hc.BodyContains = parent.BodyContains
+	}
+
+	if hc.BodyRegex == "" {
+		hc.BodyRegex = parent.BodyRegex
+	}
+
+	if hc.JSONPath == "" {
+		hc.JSONPath = parent.JSONPath
+		hc.JSONValue = parent.JSONValue
+	}
+
+	if len(hc.Headers) == 0 {
+		hc.Headers = parent.Headers
+	}
+
+	if hc.Host == "" {
+		hc.Host = parent.Host
+	}
+
+	if hc.MaxResponseTime == 0 {
+		hc.MaxResponseTime = parent.MaxResponseTime
+	}
+}
+
+func validateBackends(backends []BackendConfig) error {
+	for _, backend := range backends {
+		switch backend.ProxyProtocol {
+		case "", proxyproto.Version1, proxyproto.Version2:
+		default:
+			return fmt.Errorf("backend %s: unknown proxy_protocol version: %s", backend.URL, backend.ProxyProtocol)
+		}
+
+		if err := backend.HealthCheck.validate(); err != nil {
+			return fmt.Errorf("backend %s: %w", backend.URL, err)
+		}
+
+		if backend.Discovery != "" {
+			if err := discovery.ValidateMode(backend.Discovery); err != nil {
+				return fmt.Errorf("backend %s: %w", backend.URL, err)
+			}
+
+			backendURL, err := url.Parse(backend.URL)
+
+			if err != nil || backendURL.Hostname() == "" {
+				return fmt.Errorf("backend %s: discovery requires a host name in the url", backend.URL)
+			}
+		}
+
+		if backend.Priority < 0 {
+			return fmt.Errorf("backend %s: priority must not be negative", backend.URL)
+		}
+
+		if backend.RefreshInterval < 0 {
+			return fmt.Errorf("backend %s: refresh_interval must not be negative", backend.URL)
+		}
+	}
+
+	return nil
+}
+
+func (bhc *BackendHealthCheckConfig) validate() error {
+	if bhc == nil {
+		return nil
+	}
+
+	if bhc.Port < 0 || bhc.Port > 65535 {
+		return fmt.Errorf("invalid health_check.port: %d", bhc.Port)
+	}
+
+	if bhc.Interval < 0 || bhc.Timeout < 0 {
+		return fmt.Errorf("health_check interval and timeout must not be negative")
+	}
+
+	if bhc.SuccessThreshold < 0 || bhc.FailureThreshold < 0 {
+		return fmt.Errorf("health_check thresholds must not be negative")
+	}
+
+	return nil
+}
+
+func (dc *DiscoveryConfig) validate() error {
+	switch dc.Type {
+	case discovery.TypeFile:
+		if len(dc.Files) == 0 {
+			return fmt.Errorf("file discovery requires files")
+		}
+
+		for _, pattern := range dc.Files {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid discovery file pattern %q: %w", pattern, err)
+			}
+		}
+	case discovery.TypeHTTP:
+		catalogURL, err := url.Parse(dc.URL)
+
+		if err != nil || (catalogURL.Scheme != "http" && catalogURL.Scheme != "https") {
+			return fmt.Errorf("invalid http discovery url: %q", dc.URL)
+		}
+
+		if dc.Mapping.Address == "" && dc.Mapping.Host == "" {
+			return fmt.Errorf("http discovery mapping requires address or host")
+		}
+	default:
+		return fmt.Errorf("unknown discovery type: %s", dc.Type)
+	}
+
+	if dc.RefreshInterval < 0 || dc.Wait < 0 || dc.MaxBackoff < 0 {
+		return fmt.Errorf("discovery intervals must not be negative")
+	}
+
+	return nil
+}
+
+func (dc *DiscoveryConfig) GetProvider() discovery.Provider {
+	if dc.Type == discovery.TypeHTTP {
+		return discovery.NewHTTPProvider(discovery.HTTPConfig{
+			URL:     dc.URL,
+			Headers: dc.Headers,
+			Mapping: discovery.FieldMapping{
+				Items:        dc.Mapping.Items,
+				Address:      dc.Mapping.Address,
+				Host:         dc.Mapping.Host,
+				Port:         dc.Mapping.Port,
+				Scheme:       dc.Mapping.Scheme,
+				Weight:       dc.Mapping.Weight,
+				Priority:     dc.Mapping.Priority,
+				Labels:       dc.Mapping.Labels,
+				Healthy:      dc.Mapping.Healthy,
+				HealthyValue: dc.Mapping.HealthyValue,
+			},
+			Scheme:          dc.Scheme,
+			RefreshInterval: dc.RefreshInterval,
+			IndexHeader:     dc.IndexHeader,
+			IndexParam:      dc.IndexParam,
+			WaitParam:       dc.WaitParam,
+			Wait:            dc.Wait,
+			MaxBackoff:      dc.MaxBackoff,
+		})
+	}
+
+	return discovery.NewFileProvider(discovery.FileConfig{
+		Files:           dc.Files,
+		Scheme:          dc.Scheme,
+		RefreshInterval: dc.RefreshInterval,
+	})
+}
+
+func applyBackendDefaults(backends []BackendConfig) {
+	for i := range backends {
+		if backends[i].Weight == 0 {
+			backends[i].Weight = DefaultWeight
+		}
+
+		if backends[i].Discovery != "" && backends[i].RefreshInterval == 0 {
+			backends[i].RefreshInterval = discovery.DefaultRefreshInterval
+		}
+	}
+}
+
 func (cfg *Config) applyDefaults() {
 	// Server defaults
 	if cfg.Server.Port == 0 {
@@ -20,41 +283,353 @@ func (cfg *Config) applyDefaults() {
 	}
 
 	// HealthCheck defaults
-	if cfg.HealthCheck.Enabled == nil {
-		enabled := DefaultEnabled
-		cfg.HealthCheck.Enabled = &enabled
+	enabled := DefaultEnabled
+	jitter := DefaultJitter
+
+	cfg.HealthCheck.applyDefaults(HealthCheckConfig{
+		Enabled:          &enabled,
+		Jitter:           &jitter,
+		InitialState:     DefaultInitialState,
+		HistorySize:      health.DefaultHistorySize,
+		Type:             DefaultHealthCheckType,
+		Interval:         DefaultInterval,
+		Timeout:          DefaultTimeout,
+		Path:             DefaultPath,
+		Method:           DefaultMethod,
+		SuccessThreshold: DefaultSuccessThreshold,
+		FailureThreshold: DefaultFailureThreshold,
+	})
+
+	// Backend defaults
+	applyBackendDefaults(cfg.Backends)
+
+	// Pool defaults, inherited from the top-level settings
+	for i := range cfg.Pools {
+		pool := &cfg.Pools[i]
+
+		if pool.LoadBalancer.Strategy == "" {
+			pool.LoadBalancer.Strategy = cfg.LoadBalancer.Strategy
+		}
+
+		if pool.LoadBalancer.AdaptiveConcurrency == nil {
+			pool.LoadBalancer.AdaptiveConcurrency = cfg.LoadBalancer.AdaptiveConcurrency
+		}
+
+		if pool.LoadBalancer.ZoneAware == nil {
+			pool.LoadBalancer.ZoneAware = cfg.LoadBalancer.ZoneAware
+		}
+
+		if pool.LoadBalancer.Priority == nil {
+			pool.LoadBalancer.Priority = cfg.LoadBalancer.Priority
+		}
+
+		pool.HealthCheck.applyDefaults(cfg.HealthCheck)
+		applyBackendDefaults(pool.Backends)
 	}
 
-	if cfg.HealthCheck.Interval == 0 {
-		cfg.HealthCheck.Interval = DefaultInterval
+	// Route defaults
+	for i := range cfg.Routes {
+		if cfg.Routes[i].Name == "" {
+			cfg.Routes[i].Name = fmt.Sprintf("route-%d", i)
+		}
+
+		if cfg.Routes[i].RateLimit != nil && cfg.Routes[i].RateLimit.Key == "" {
+			cfg.Routes[i].RateLimit.Key = DefaultRateLimitKey
+		}
 	}
 
-	if cfg.HealthCheck.Timeout == 0 {
-		cfg.HealthCheck.Timeout = DefaultTimeout
+	// RateLimit defaults
+	if cfg.RateLimit != nil && cfg.RateLimit.Key == "" {
+		cfg.RateLimit.Key = DefaultRateLimitKey
 	}
 
-	if cfg.HealthCheck.Path == "" {
-		cfg.HealthCheck.Path = DefaultPath
+	// Listener defaults
+	for i := range cfg.Listeners {
+		if cfg.Listeners[i].Name == "" {
+			cfg.Listeners[i].Name = fmt.Sprintf("listener-%d", i)
+		}
 	}
 
-	if cfg.HealthCheck.Method == "" {
-		cfg.HealthCheck.Method = DefaultMethod
+	// Readiness defaults
+	if cfg.Readiness != nil {
+		if cfg.Readiness.Path == "" {
+			cfg.Readiness.Path = DefaultReadinessPath
+		}
+
+		if cfg.Readiness.MinHealthyBackends == 0 {
+			cfg.Readiness.MinHealthyBackends = DefaultMinHealthy
+		}
 	}
 
-	if cfg.HealthCheck.SuccessThreshold == 0 {
-		cfg.HealthCheck.SuccessThreshold = DefaultSuccessThreshold
+	// Admin defaults
+	if cfg.Admin.Enabled == nil {
+		enabled := DefaultAdminEnabled
+		cfg.Admin.Enabled = &enabled
 	}
 
-	if cfg.HealthCheck.FailureThreshold == 0 {
-		cfg.HealthCheck.FailureThreshold = DefaultFailureThreshold
+	if cfg.Admin.Address == "" {
+		cfg.Admin.Address = DefaultAdminAddress
 	}
 
-	// Backend defaults
-	for i := range cfg.Backends {
-		if cfg.Backends[i].Weight == 0 {
-			cfg.Backends[i].Weight = DefaultWeight
+	if cfg.Admin.Port == 0 {
+		cfg.Admin.Port = DefaultAdminPort
+	}
+}
+
+func (rlc *RateLimitConfig) validate() error {
+	if rlc == nil {
+		return nil
+	}
+
+	if rlc.Rate <= 0 {
+		return fmt.Errorf("rate_limit.rate must be greater than zero")
+	}
+
+	if _, err := ratelimit.NewKeyFunc(rlc.Key); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (zac *ZoneAwareConfig) validate() error {
+	if zac == nil {
+		return nil
+	}
+
+	if zac.Zone == "" {
+		return fmt.Errorf("zone_aware.zone is required")
+	}
+
+	if zac.SpilloverThreshold < 0 || zac.SpilloverThreshold > 1 {
+		return fmt.Errorf("zone_aware.spillover_threshold must be between 0 and 1")
+	}
+
+	return nil
+}
+
+func (sc *SplitConfig) validate(poolNames map[string]bool) error {
+	seen := make(map[string]bool, len(sc.Pools))
+	var totalWeight uint64
+
+	for _, destination := range sc.Pools {
+		if !poolNames[destination.Pool] {
+			return fmt.Errorf("split references unknown pool: %s", destination.Pool)
+		}
+
+		if seen[destination.Pool] {
+			return fmt.Errorf("split references pool %s more than once", destination.Pool)
+		}
+
+		seen[destination.Pool] = true
+		totalWeight += destination.Weight
+	}
+
+	if totalWeight == 0 {
+		return fmt.Errorf("split requires a pool with a weight")
+	}
+
+	return nil
+}
+
+func (mc *MirrorConfig) validate(poolNames map[string]bool) error {
+	if mc == nil {
+		return nil
+	}
+
+	if !poolNames[mc.Pool] {
+		return fmt.Errorf("mirror references unknown pool: %s", mc.Pool)
+	}
+
+	if mc.Percent <= 0 || mc.Percent > 100 {
+		return fmt.Errorf("mirror.percent must be greater than 0 and at most 100")
+	}
+
+	if mc.MaxBodySize < 0 || mc.Timeout < 0 || mc.MaxInFlight < 0 {
+		return fmt.Errorf("mirror limits must not be negative")
+	}
+
+	return nil
+}
+
+func (hc *HedgingConfig) validate() error {
+	if hc == nil {
+		return nil
+	}
+
+	if hc.Delay < 0 {
+		return fmt.Errorf("hedging.delay must not be negative")
+	}
+
+	if hc.Percentile < 0 || hc.Percentile > 100 {
+		return fmt.Errorf("hedging.percentile must be between 0 and 100")
+	}
+
+	if hc.MaxRatio < 0 || hc.MaxRatio > 1 {
+		return fmt.Errorf("hedging.max_ratio must be between 0 and 1")
+	}
+
+	// Sending a request twice is only safe for methods without side
+	// effects. PUT and DELETE are idempotent, but two copies racing on
+	// different backends can still leave an unexpected state.
+	for _, method := range hc.Methods {
+		switch strings.ToUpper(method) {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		default:
+			return fmt.Errorf("hedging.methods: %s is not a safe method", method)
 		}
 	}
+
+	return nil
+}
+
+func (cfg *Config) validate() error {
+	poolNames := map[string]bool{DefaultPoolName: true}
+
+	if err := validateBackends(cfg.Backends); err != nil {
+		return err
+	}
+
+	for _, pool := range cfg.GetPools() {
+		if err := pool.HealthCheck.validate(); err != nil {
+			return fmt.Errorf("pool %s: %w", pool.Name, err)
+		}
+
+		for _, discoveryConfig := range pool.Discovery {
+			if err := discoveryConfig.validate(); err != nil {
+				return fmt.Errorf("pool %s: %w", pool.Name, err)
+			}
+		}
+
+		if adaptive := pool.LoadBalancer.AdaptiveConcurrency; adaptive != nil {
+			if err := concurrency.ValidateAlgorithm(adaptive.Algorithm); err != nil {
+				return fmt.Errorf("pool %s: %w", pool.Name, err)
+			}
+		}
+
+		if err := pool.LoadBalancer.ZoneAware.validate(); err != nil {
+			return fmt.Errorf("pool %s: %w", pool.Name, err)
+		}
+
+		if priority := pool.LoadBalancer.Priority; priority != nil && priority.OverprovisioningFactor < 0 {
+			return fmt.Errorf("pool %s: priority.overprovisioning_factor must not be negative", pool.Name)
+		}
+	}
+
+	for _, pool := range cfg.Pools {
+		if pool.Name == "" {
+			return fmt.Errorf("pool name is required")
+		}
+
+		if err := validateBackends(pool.Backends); err != nil {
+			return fmt.Errorf("pool %s: %w", pool.Name, err)
+		}
+
+		if poolNames[pool.Name] {
+			return fmt.Errorf("duplicate pool name: %s", pool.Name)
+		}
+
+		poolNames[pool.Name] = true
+	}
+
+	// The admin API can change the traffic, so it never shares the public
+	// listener
+	if cfg.IsAdminEnabled() && cfg.Admin.Port == cfg.Server.Port {
+		return fmt.Errorf("admin.port must differ from server.port")
+	}
+
+	if _, err := clientip.NewResolver(cfg.Forwarding.TrustedProxies); err != nil {
+		return err
+	}
+
+	if err := cfg.RateLimit.validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.Mirror.validate(poolNames); err != nil {
+		return err
+	}
+
+	if err := cfg.Hedging.validate(); err != nil {
+		return err
+	}
+
+	for _, route := range cfg.Routes {
+		if route.Split != nil {
+			if route.Pool != "" {
+				return fmt.Errorf("route %s: pool and split are mutually exclusive", route.Name)
+			}
+
+			if err := route.Split.validate(poolNames); err != nil {
+				return fmt.Errorf("route %s: %w", route.Name, err)
+			}
+		} else if !poolNames[route.Pool] {
+			return fmt.Errorf("route %s references unknown pool: %s", route.Name, route.Pool)
+		}
+
+		if err := route.RateLimit.validate(); err != nil {
+			return fmt.Errorf("route %s: %w", route.Name, err)
+		}
+
+		if err := route.Mirror.validate(poolNames); err != nil {
+			return fmt.Errorf("route %s: %w", route.Name, err)
+		}
+
+		if err := route.Hedging.validate(); err != nil {
+			return fmt.Errorf("route %s: %w", route.Name, err)
+		}
+
+		if _, err := route.GetMatch(); err != nil {
+			return fmt.Errorf("route %s: %w", route.Name, err)
+		}
+	}
+
+	for _, listener := range cfg.Listeners {
+		if listener.Port == 0 {
+			return fmt.Errorf("listener %s: port is required", listener.Name)
+		}
+
+		switch listener.Mode {
+		case listeners.ModeTCP, listeners.ModeUDP, listeners.ModeTLSPassthrough:
+		default:
+			return fmt.Errorf("listener %s: unknown mode: %s", listener.Name, listener.Mode)
+		}
+
+		if listener.Mode == listeners.ModeUDP && listener.ProxyProtocol {
+			return fmt.Errorf("listener %s: proxy_protocol is not supported in udp mode", listener.Name)
+		}
+
+		if len(listener.SNI) > 0 && listener.Mode != listeners.ModeTLSPassthrough {
+			return fmt.Errorf("listener %s: sni is only supported in %s mode", listener.Name, listeners.ModeTLSPassthrough)
+		}
+
+		for serverName, pool := range listener.SNI {
+			if !poolNames[pool] {
+				return fmt.Errorf("listener %s: server name %s references unknown pool: %s", listener.Name, serverName, pool)
+			}
+		}
+
+		// A TLS passthrough listener may route every name through sni alone
+		if listener.Pool == "" && listener.Mode == listeners.ModeTLSPassthrough && len(listener.SNI) > 0 {
+			continue
+		}
+
+		if !poolNames[listener.Pool] {
+			return fmt.Errorf("listener %s references unknown pool: %s", listener.Name, listener.Pool)
+		}
+	}
+
+	if cfg.Readiness != nil {
+		if !strings.HasPrefix(cfg.Readiness.Path, "/") {
+			return fmt.Errorf("readiness.path must start with /")
+		}
+
+		if cfg.Readiness.MinHealthyBackends < 0 {
+			return fmt.Errorf("readiness.min_healthy_backends must not be negative")
+		}
+	}
+
+	return nil
 }
 
 func LoadConfig(configPath string) (*Config, error) {
@@ -71,23 +646,384 @@ func LoadConfig(configPath string) (*Config, error) {
 	}
 
 	config.applyDefaults()
+
+	err = config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return config, nil
 }
 
-func (cfg *Config) GetHealthConfig() *health.Config {
+func (hc *HealthCheckConfig) GetHealthConfig() *health.Config {
 	return &health.Config{
-		Interval:         cfg.HealthCheck.Interval,
-		Timeout:          cfg.HealthCheck.Timeout,
-		Path:             cfg.HealthCheck.Path,
-		Method:           cfg.HealthCheck.Method,
-		SuccessThreshold: cfg.HealthCheck.SuccessThreshold,
-		FailureThreshold: cfg.HealthCheck.FailureThreshold,
+		Type:                hc.Type,
+		Interval:            hc.Interval,
+		Timeout:             hc.Timeout,
+		Path:                hc.Path,
+		Method:              hc.Method,
+		SuccessThreshold:    hc.SuccessThreshold,
+		FailureThreshold:    hc.FailureThreshold,
+		GRPCService:         hc.GRPCService,
+		Command:             hc.Command,
+		Jitter:              hc.GetJitter(),
+		MaxBackoffInterval:  hc.MaxBackoffInterval,
+		MaxConcurrentChecks: hc.MaxConcurrentChecks,
+		InitialState:        hc.InitialState,
+		HistorySize:         hc.HistorySize,
+		FlapDetection:       hc.GetFlapDetectionConfig(),
+		Webhooks:            hc.GetWebhooks(),
+		ExpectedStatuses:    hc.ExpectedStatuses,
+		BodyContains:        hc.BodyContains,
+		BodyRegex:           hc.BodyRegex,
+		JSONPath:            hc.JSONPath,
+		JSONValue:           hc.JSONValue,
+		Headers:             hc.Headers,
+		Host:                hc.Host,
+		MaxResponseTime:     hc.MaxResponseTime,
 	}
 }
 
-func (cfg *Config) IsHealthCheckEnabled() bool {
-	if cfg.HealthCheck.Enabled == nil {
+func (bc *BackendConfig) GetDNSConfig() (discovery.DNSConfig, error) {
+	backendURL, err := url.Parse(bc.URL)
+
+	if err != nil {
+		return discovery.DNSConfig{}, err
+	}
+
+	return discovery.DNSConfig{
+		URL:             backendURL,
+		Mode:            bc.Discovery,
+		Weight:          bc.Weight,
+		Labels:          bc.Labels,
+		Priority:        bc.Priority,
+		RefreshInterval: bc.RefreshInterval,
+	}, nil
+}
+
+func (bc *BackendConfig) GetHealthCheckOverride() *backend.HealthCheckOverride {
+	if bc.HealthCheck == nil {
+		return nil
+	}
+
+	return &backend.HealthCheckOverride{
+		Path:             bc.HealthCheck.Path,
+		Port:             bc.HealthCheck.Port,
+		Method:           bc.HealthCheck.Method,
+		Interval:         bc.HealthCheck.Interval,
+		Timeout:          bc.HealthCheck.Timeout,
+		SuccessThreshold: bc.HealthCheck.SuccessThreshold,
+		FailureThreshold: bc.HealthCheck.FailureThreshold,
+	}
+}
+
+func (hc *HealthCheckConfig) GetJitter() float64 {
+	if hc.Jitter == nil {
+		return DefaultJitter
+	}
+	return *hc.Jitter
+}
+
+func (hc *HealthCheckConfig) GetFlapDetectionConfig() *health.FlapDetectionConfig {
+	if hc.FlapDetection == nil {
+		return nil
+	}
+
+	return &health.FlapDetectionConfig{
+		MaxTransitions: hc.FlapDetection.MaxTransitions,
+		Window:         hc.FlapDetection.Window,
+		Cooldown:       hc.FlapDetection.Cooldown,
+	}
+}
+
+func (hc *HealthCheckConfig) GetWebhooks() []health.WebhookConfig {
+	if len(hc.Webhooks) == 0 {
+		return nil
+	}
+
+	webhooks := make([]health.WebhookConfig, 0, len(hc.Webhooks))
+
+	for _, webhook := range hc.Webhooks {
+		webhooks = append(webhooks, health.WebhookConfig{
+			URL:     webhook.URL,
+			Headers: webhook.Headers,
+		})
+	}
+
+	return webhooks
+}
+
+func (hc *HealthCheckConfig) validate() error {
+	if jitter := hc.GetJitter(); jitter < 0 || jitter > 1 {
+		return fmt.Errorf("health_check.jitter must be between 0 and 1")
+	}
+
+	if hc.MaxBackoffInterval < 0 {
+		return fmt.Errorf("health_check.max_backoff_interval must not be negative")
+	}
+
+	if hc.MaxConcurrentChecks < 0 {
+		return fmt.Errorf("health_check.max_concurrent_checks must not be negative")
+	}
+
+	if hc.HistorySize < 0 {
+		return fmt.Errorf("health_check.history_size must not be negative")
+	}
+
+	if flap := hc.FlapDetection; flap != nil {
+		if flap.MaxTransitions <= 0 {
+			return fmt.Errorf("health_check.flap_detection.max_transitions must be greater than zero")
+		}
+
+		if flap.Window < 0 || flap.Cooldown < 0 {
+			return fmt.Errorf("health_check.flap_detection window and cooldown must not be negative")
+		}
+	}
+
+	switch hc.InitialState {
+	case "", health.InitialStateOptimistic, health.InitialStatePessimistic:
+	default:
+		return fmt.Errorf("unknown health_check.initial_state: %s", hc.InitialState)
+	}
+
+	for _, webhook := range hc.Webhooks {
+		webhookURL, err := url.Parse(webhook.URL)
+
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") {
+			return fmt.Errorf("invalid health_check webhook url: %q", webhook.URL)
+		}
+	}
+
+	if _, err := health.NewProber(hc.GetHealthConfig()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (hc *HealthCheckConfig) IsEnabled() bool {
+	if hc.Enabled == nil {
 		return DefaultEnabled
 	}
-	return *cfg.HealthCheck.Enabled
+	return *hc.Enabled
+}
+
+func (cfg *Config) GetHealthConfig() *health.Config {
+	return cfg.HealthCheck.GetHealthConfig()
+}
+
+func (cfg *Config) IsHealthCheckEnabled() bool {
+	return cfg.HealthCheck.IsEnabled()
+}
+
+func (cfg *Config) GetPools() []PoolConfig {
+	pools := make([]PoolConfig, 0, len(cfg.Pools)+1)
+
+	pools = append(pools, PoolConfig{
+		Name:         DefaultPoolName,
+		LoadBalancer: cfg.LoadBalancer,
+		Backends:     cfg.Backends,
+		HealthCheck:  cfg.HealthCheck,
+		Discovery:    cfg.Discovery,
+	})
+
+	return append(pools, cfg.Pools...)
+}
+
+// HasBackendSources reports whether backends are configured for the pool,
+// statically or through discovery.
+func (pc *PoolConfig) HasBackendSources() bool {
+	return len(pc.Backends) > 0 || len(pc.Discovery) > 0
+}
+
+// UsesPriorities reports whether requests of the pool are spread over
+// priority levels, either because load_balancer.priority is set or because
+// one of its backends has a priority. Priorities reported by discovery are
+// only known at runtime, so pools with SRV, file or HTTP discovery always
+// use them.
+func (pc *PoolConfig) UsesPriorities() bool {
+	if pc.LoadBalancer.Priority != nil || len(pc.Discovery) > 0 {
+		return true
+	}
+
+	for _, backend := range pc.Backends {
+		if backend.Priority != 0 || backend.Discovery == discovery.ModeDNSSRV {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (pc *PoolConfig) GetOverprovisioningFactor() float64 {
+	if pc.LoadBalancer.Priority == nil {
+		return strategies.DefaultOverprovisioningFactor
+	}
+
+	return pc.LoadBalancer.Priority.OverprovisioningFactor
+}
+
+func (rc *RouteConfig) GetMatch() (*router.Match, error) {
+	match := &router.Match{
+		Host:        rc.Match.Host,
+		PathPrefix:  rc.Match.PathPrefix,
+		Methods:     rc.Match.Methods,
+		Headers:     rc.Match.Headers,
+		Cookies:     rc.Match.Cookies,
+		QueryParams: rc.Match.QueryParams,
+	}
+
+	if rc.Match.PathRegex != "" {
+		pathRegex, err := regexp.Compile(rc.Match.PathRegex)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid path_regex: %w", err)
+		}
+
+		match.PathRegex = pathRegex
+	}
+
+	if len(rc.Match.HeaderRegex) > 0 {
+		match.HeaderRegex = make(map[string]*regexp.Regexp, len(rc.Match.HeaderRegex))
+
+		for name, pattern := range rc.Match.HeaderRegex {
+			headerRegex, err := regexp.Compile(pattern)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid header_regex for %s: %w", name, err)
+			}
+
+			match.HeaderRegex[name] = headerRegex
+		}
+	}
+
+	for _, entry := range rc.Match.SourceCIDRs {
+		// A bare address matches that address only
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+
+			if ip == nil {
+				return nil, fmt.Errorf("invalid source_cidrs entry: %s", entry)
+			}
+
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid source_cidrs entry: %s", entry)
+		}
+
+		match.SourceRanges = append(match.SourceRanges, network)
+	}
+
+	return match, nil
+}
+
+func (rc *RouteConfig) GetSplitConfig() split.Config {
+	splitConfig := split.Config{
+		Name:         rc.Name,
+		Destinations: make([]split.Destination, 0, len(rc.Split.Pools)),
+		HashHeader:   rc.Split.HashHeader,
+		HashCookie:   rc.Split.HashCookie,
+	}
+
+	for _, destination := range rc.Split.Pools {
+		splitConfig.Destinations = append(splitConfig.Destinations, split.Destination{
+			Pool:   destination.Pool,
+			Weight: destination.Weight,
+		})
+	}
+
+	return splitConfig
+}
+
+func (hoc *HeaderOperationsConfig) getOperations() headers.Operations {
+	return headers.Operations{
+		Set:    hoc.Set,
+		Append: hoc.Append,
+		Remove: hoc.Remove,
+		Rename: hoc.Rename,
+	}
+}
+
+func (hrc *HeaderRulesConfig) GetRules() *headers.Rules {
+	return &headers.Rules{
+		Request:  hrc.Request.getOperations(),
+		Response: hrc.Response.getOperations(),
+	}
+}
+
+func (rlc *RateLimitConfig) GetRateLimitConfig() *ratelimit.Config {
+	return &ratelimit.Config{
+		Rate:        rlc.Rate,
+		Burst:       rlc.Burst,
+		Key:         rlc.Key,
+		IdleTimeout: rlc.IdleTimeout,
+		MaxKeys:     rlc.MaxKeys,
+	}
+}
+
+func (mc *MirrorConfig) GetMirrorConfig() *mirror.Config {
+	return &mirror.Config{
+		Pool:        mc.Pool,
+		Percent:     mc.Percent,
+		MaxBodySize: mc.MaxBodySize,
+		Timeout:     mc.Timeout,
+		MaxInFlight: mc.MaxInFlight,
+	}
+}
+
+func (hc *HedgingConfig) GetHedgingConfig() *hedge.Config {
+	return &hedge.Config{
+		Delay:      hc.Delay,
+		Percentile: hc.Percentile,
+		MaxRatio:   hc.MaxRatio,
+		Methods:    hc.Methods,
+	}
+}
+
+func (cfg *Config) IsAdminEnabled() bool {
+	if cfg.Admin.Enabled == nil {
+		return DefaultAdminEnabled
+	}
+	return *cfg.Admin.Enabled
+}
+
+func (acc *AdaptiveConcurrencyConfig) GetConcurrencyConfig() concurrency.Config {
+	return concurrency.Config{
+		Algorithm:        acc.Algorithm,
+		InitialLimit:     acc.InitialLimit,
+		MinLimit:         acc.MinLimit,
+		MaxLimit:         acc.MaxLimit,
+		BackoffRatio:     acc.BackoffRatio,
+		LatencyThreshold: acc.LatencyThreshold,
+		Tolerance:        acc.Tolerance,
+		Smoothing:        acc.Smoothing,
+	}
+}
+
+func (zac *ZoneAwareConfig) GetZoneAwareConfig() strategies.ZoneAwareConfig {
+	return strategies.ZoneAwareConfig{
+		Zone:               zac.Zone,
+		Label:              zac.Label,
+		SpilloverThreshold: zac.SpilloverThreshold,
+	}
+}
+
+func (lc *ListenerConfig) GetListenerConfig() *listeners.Config {
+	return &listeners.Config{
+		Name:           lc.Name,
+		Address:        fmt.Sprintf(":%d", lc.Port),
+		Mode:           lc.Mode,
+		IdleTimeout:    lc.IdleTimeout,
+		ConnectTimeout: lc.ConnectTimeout,
+		ProxyProtocol:  lc.ProxyProtocol,
+		MaxSessions:    lc.MaxSessions,
+	}
 }
@@ -3,26 +3,237 @@ package config
 import "time"
 
 type ServerConfig struct {
-	Port int `yaml:"port,omitempty"`
+	Port                     int           `yaml:"port,omitempty"`
+	ProxyProtocol            bool          `yaml:"proxy_protocol,omitempty"`
+	ProxyProtocolReadTimeout time.Duration `yaml:"proxy_protocol_read_timeout,omitempty"`
+}
+
+type BackendHealthCheckConfig struct {
+	Path             string        `yaml:"path,omitempty"`
+	Port             int           `yaml:"port,omitempty"`
+	Method           string        `yaml:"method,omitempty"`
+	Interval         time.Duration `yaml:"interval,omitempty"`
+	Timeout          time.Duration `yaml:"timeout,omitempty"`
+	SuccessThreshold int           `yaml:"success_threshold,omitempty"`
+	FailureThreshold int           `yaml:"failure_threshold,omitempty"`
 }
 
 type BackendConfig struct {
-	URL    string `yaml:"url"`
-	Weight uint64 `yaml:"weight,omitempty"`
+	URL             string                    `yaml:"url"`
+	Weight          uint64                    `yaml:"weight,omitempty"`
+	Labels          map[string]string         `yaml:"labels,omitempty"`
+	Priority        int                       `yaml:"priority,omitempty"`
+	ProxyProtocol   string                    `yaml:"proxy_protocol,omitempty"`
+	HealthCheck     *BackendHealthCheckConfig `yaml:"health_check,omitempty"`
+	Discovery       string                    `yaml:"discovery,omitempty"`
+	RefreshInterval time.Duration             `yaml:"refresh_interval,omitempty"`
+}
+
+type WebhookConfig struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+type FlapDetectionConfig struct {
+	MaxTransitions int           `yaml:"max_transitions,omitempty"`
+	Window         time.Duration `yaml:"window,omitempty"`
+	Cooldown       time.Duration `yaml:"cooldown,omitempty"`
 }
 
 type HealthCheckConfig struct {
 	Enabled          *bool         `yaml:"enabled,omitempty"`
+	Type             string        `yaml:"type,omitempty"`
 	Interval         time.Duration `yaml:"interval,omitempty"`
 	Timeout          time.Duration `yaml:"timeout,omitempty"`
 	Path             string        `yaml:"path,omitempty"`
 	Method           string        `yaml:"method,omitempty"`
 	SuccessThreshold int           `yaml:"success_threshold,omitempty"`
 	FailureThreshold int           `yaml:"failure_threshold,omitempty"`
+	GRPCService      string        `yaml:"grpc_service,omitempty"`
+	Command          []string      `yaml:"command,omitempty"`
+
+	Jitter              *float64      `yaml:"jitter,omitempty"`
+	MaxBackoffInterval  time.Duration `yaml:"max_backoff_interval,omitempty"`
+	MaxConcurrentChecks int           `yaml:"max_concurrent_checks,omitempty"`
+	InitialState        string        `yaml:"initial_state,omitempty"`
+
+	HistorySize   int                  `yaml:"history_size,omitempty"`
+	FlapDetection *FlapDetectionConfig `yaml:"flap_detection,omitempty"`
+
+	Webhooks []WebhookConfig `yaml:"webhooks,omitempty"`
+
+	ExpectedStatuses []string          `yaml:"expected_statuses,omitempty"`
+	BodyContains     string            `yaml:"body_contains,omitempty"`
+	BodyRegex        string            `yaml:"body_regex,omitempty"`
+	JSONPath         string            `yaml:"json_path,omitempty"`
+	JSONValue        string            `yaml:"json_value,omitempty"`
+	Headers          map[string]string `yaml:"headers,omitempty"`
+	Host             string            `yaml:"host,omitempty"`
+	MaxResponseTime  time.Duration     `yaml:"max_response_time,omitempty"`
+}
+
+type AdaptiveConcurrencyConfig struct {
+	Algorithm        string        `yaml:"algorithm,omitempty"`
+	InitialLimit     int           `yaml:"initial_limit,omitempty"`
+	MinLimit         int           `yaml:"min_limit,omitempty"`
+	MaxLimit         int           `yaml:"max_limit,omitempty"`
+	BackoffRatio     float64       `yaml:"backoff_ratio,omitempty"`
+	LatencyThreshold time.Duration `yaml:"latency_threshold,omitempty"`
+	Tolerance        float64       `yaml:"tolerance,omitempty"`
+	Smoothing        float64       `yaml:"smoothing,omitempty"`
+}
+
+type ZoneAwareConfig struct {
+	Zone               string  `yaml:"zone"`
+	Label              string  `yaml:"label,omitempty"`
+	SpilloverThreshold float64 `yaml:"spillover_threshold,omitempty"`
+}
+
+type PriorityConfig struct {
+	OverprovisioningFactor float64 `yaml:"overprovisioning_factor,omitempty"`
 }
 
 type LoadBalancerConfig struct {
-	Strategy string `yaml:"strategy,omitempty"`
+	Strategy            string                     `yaml:"strategy,omitempty"`
+	AdaptiveConcurrency *AdaptiveConcurrencyConfig `yaml:"adaptive_concurrency,omitempty"`
+	ZoneAware           *ZoneAwareConfig           `yaml:"zone_aware,omitempty"`
+	Priority            *PriorityConfig            `yaml:"priority,omitempty"`
+}
+
+type FieldMappingConfig struct {
+	Items        string            `yaml:"items,omitempty"`
+	Address      string            `yaml:"address,omitempty"`
+	Host         string            `yaml:"host,omitempty"`
+	Port         string            `yaml:"port,omitempty"`
+	Scheme       string            `yaml:"scheme,omitempty"`
+	Weight       string            `yaml:"weight,omitempty"`
+	Priority     string            `yaml:"priority,omitempty"`
+	Labels       map[string]string `yaml:"labels,omitempty"`
+	Healthy      string            `yaml:"healthy,omitempty"`
+	HealthyValue string            `yaml:"healthy_value,omitempty"`
+}
+
+type DiscoveryConfig struct {
+	Type            string        `yaml:"type"`
+	Files           []string      `yaml:"files,omitempty"`
+	Scheme          string        `yaml:"scheme,omitempty"`
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty"`
+
+	// HTTP catalog
+	URL         string             `yaml:"url,omitempty"`
+	Headers     map[string]string  `yaml:"headers,omitempty"`
+	Mapping     FieldMappingConfig `yaml:"mapping,omitempty"`
+	IndexHeader string             `yaml:"index_header,omitempty"`
+	IndexParam  string             `yaml:"index_param,omitempty"`
+	WaitParam   string             `yaml:"wait_param,omitempty"`
+	Wait        time.Duration      `yaml:"wait,omitempty"`
+	MaxBackoff  time.Duration      `yaml:"max_backoff,omitempty"`
+}
+
+type PoolConfig struct {
+	Name         string             `yaml:"name"`
+	LoadBalancer LoadBalancerConfig `yaml:"load_balancer,omitempty"`
+	Backends     []BackendConfig    `yaml:"backends,omitempty"`
+	HealthCheck  HealthCheckConfig  `yaml:"health_check,omitempty"`
+	Discovery    []DiscoveryConfig  `yaml:"discovery,omitempty"`
+}
+
+type RouteMatchConfig struct {
+	Host       string            `yaml:"host,omitempty"`
+	PathPrefix string            `yaml:"path_prefix,omitempty"`
+	PathRegex  string            `yaml:"path_regex,omitempty"`
+	Methods    []string          `yaml:"methods,omitempty"`
+	Headers    map[string]string `yaml:"headers,omitempty"`
+
+	HeaderRegex map[string]string `yaml:"header_regex,omitempty"`
+	Cookies     map[string]string `yaml:"cookies,omitempty"`
+	QueryParams map[string]string `yaml:"query_params,omitempty"`
+	SourceCIDRs []string          `yaml:"source_cidrs,omitempty"`
+}
+
+type HeaderOperationsConfig struct {
+	Set    map[string]string `yaml:"set,omitempty"`
+	Append map[string]string `yaml:"append,omitempty"`
+	Remove []string          `yaml:"remove,omitempty"`
+	Rename map[string]string `yaml:"rename,omitempty"`
+}
+
+type HeaderRulesConfig struct {
+	Request  HeaderOperationsConfig `yaml:"request,omitempty"`
+	Response HeaderOperationsConfig `yaml:"response,omitempty"`
+}
+
+type RateLimitConfig struct {
+	Rate        float64       `yaml:"rate,omitempty"`
+	Burst       int           `yaml:"burst,omitempty"`
+	Key         string        `yaml:"key,omitempty"`
+	IdleTimeout time.Duration `yaml:"idle_timeout,omitempty"`
+	MaxKeys     int           `yaml:"max_keys,omitempty"`
+}
+
+type SplitDestinationConfig struct {
+	Pool   string `yaml:"pool"`
+	Weight uint64 `yaml:"weight"`
+}
+
+type SplitConfig struct {
+	Pools      []SplitDestinationConfig `yaml:"pools"`
+	HashHeader string                   `yaml:"hash_header,omitempty"`
+	HashCookie string                   `yaml:"hash_cookie,omitempty"`
+}
+
+type MirrorConfig struct {
+	Pool        string        `yaml:"pool"`
+	Percent     float64       `yaml:"percent"`
+	MaxBodySize int64         `yaml:"max_body_size,omitempty"`
+	Timeout     time.Duration `yaml:"timeout,omitempty"`
+	MaxInFlight int           `yaml:"max_in_flight,omitempty"`
+}
+
+type HedgingConfig struct {
+	Delay      time.Duration `yaml:"delay,omitempty"`
+	Percentile float64       `yaml:"percentile,omitempty"`
+	MaxRatio   float64       `yaml:"max_ratio,omitempty"`
+	Methods    []string      `yaml:"methods,omitempty"`
+}
+
+type RouteConfig struct {
+	Name      string            `yaml:"name,omitempty"`
+	Match     RouteMatchConfig  `yaml:"match,omitempty"`
+	Pool      string            `yaml:"pool,omitempty"`
+	Split     *SplitConfig      `yaml:"split,omitempty"`
+	Headers   HeaderRulesConfig `yaml:"headers,omitempty"`
+	RateLimit *RateLimitConfig  `yaml:"rate_limit,omitempty"`
+	Mirror    *MirrorConfig     `yaml:"mirror,omitempty"`
+	Hedging   *HedgingConfig    `yaml:"hedging,omitempty"`
+}
+
+type AdminConfig struct {
+	Enabled *bool  `yaml:"enabled,omitempty"`
+	Address string `yaml:"address,omitempty"`
+	Port    int    `yaml:"port,omitempty"`
+}
+
+type ReadinessConfig struct {
+	Path               string `yaml:"path,omitempty"`
+	MinHealthyBackends int    `yaml:"min_healthy_backends,omitempty"`
+}
+
+type ForwardingConfig struct {
+	TrustedProxies  []string `yaml:"trusted_proxies,omitempty"`
+	ForwardedHeader bool     `yaml:"forwarded_header,omitempty"`
+}
+
+type ListenerConfig struct {
+	Name           string            `yaml:"name,omitempty"`
+	Port           int               `yaml:"port"`
+	Mode           string            `yaml:"mode"`
+	Pool           string            `yaml:"pool,omitempty"`
+	SNI            map[string]string `yaml:"sni,omitempty"`
+	IdleTimeout    time.Duration     `yaml:"idle_timeout,omitempty"`
+	ConnectTimeout time.Duration     `yaml:"connect_timeout,omitempty"`
+	ProxyProtocol  bool              `yaml:"proxy_protocol,omitempty"`
+	MaxSessions    int               `yaml:"max_sessions,omitempty"`
 }
 
 type Config struct {
@@ -30,6 +241,17 @@ type Config struct {
 	LoadBalancer LoadBalancerConfig `yaml:"load_balancer,omitempty"`
 	Backends     []BackendConfig    `yaml:"backends,omitempty"`
 	HealthCheck  HealthCheckConfig  `yaml:"health_check,omitempty"`
+	Discovery    []DiscoveryConfig  `yaml:"discovery,omitempty"`
+	Pools        []PoolConfig       `yaml:"pools,omitempty"`
+	Routes       []RouteConfig      `yaml:"routes,omitempty"`
+	Headers      HeaderRulesConfig  `yaml:"headers,omitempty"`
+	Forwarding   ForwardingConfig   `yaml:"forwarding,omitempty"`
+	RateLimit    *RateLimitConfig   `yaml:"rate_limit,omitempty"`
+	Mirror       *MirrorConfig      `yaml:"mirror,omitempty"`
+	Hedging      *HedgingConfig     `yaml:"hedging,omitempty"`
+	Admin        AdminConfig        `yaml:"admin,omitempty"`
+	Listeners    []ListenerConfig   `yaml:"listeners,omitempty"`
+	Readiness    *ReadinessConfig   `yaml:"readiness,omitempty"`
 }
 
 const (
@@ -38,9 +260,21 @@ const (
 	DefaultEnabled          = true
 	DefaultInterval         = 10 * time.Second
 	DefaultTimeout          = 5 * time.Second
+	DefaultHealthCheckType  = "http"
 	DefaultPath             = "/health"
 	DefaultMethod           = "GET"
 	DefaultWeight           = uint64(1)
 	DefaultSuccessThreshold = 3
 	DefaultFailureThreshold = 3
+	DefaultJitter           = 0.1
+	DefaultInitialState     = "optimistic"
+	DefaultReadinessPath    = "/ready"
+	DefaultMinHealthy       = 1
+	DefaultFlapWindow       = 5 * time.Minute
+	DefaultFlapCooldown     = 5 * time.Minute
+	DefaultPoolName         = "default"
+	DefaultAdminEnabled     = false
+	DefaultAdminAddress     = "127.0.0.1"
+	DefaultAdminPort        = 9090
+	DefaultRateLimitKey     = "client_ip"
 )
@@ -0,0 +1,202 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ModeDNS    = "dns"
+	ModeDNSSRV = "dns-srv"
+
+	DefaultRefreshInterval = 30 * time.Second
+	resolveTimeout         = 10 * time.Second
+)
+
+// Resolver is the subset of net.Resolver used for discovery, so tests can
+// use a stub.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+type DNSConfig struct {
+	// URL is the backend URL whose host is resolved. In SRV mode the host is
+	// the SRV name and the port comes from the records.
+	URL             *url.URL
+	Mode            string
+	Weight          uint64
+	Labels          map[string]string
+	Priority        int
+	RefreshInterval time.Duration
+}
+
+// DNSProvider expands a DNS name into one target per resolved address and
+// resolves it again every RefreshInterval. Record TTLs are not known to the
+// resolver, so the interval should not exceed the TTL of the records.
+type DNSProvider struct {
+	config   DNSConfig
+	resolver Resolver
+}
+
+func NewDNSProvider(config DNSConfig, resolver Resolver) *DNSProvider {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultRefreshInterval
+	}
+
+	return &DNSProvider{
+		config:   config,
+		resolver: resolver,
+	}
+}
+
+func (dp *DNSProvider) Name() string {
+	return dp.config.Mode + ":" + dp.config.URL.Hostname()
+}
+
+// Resolve looks the name up once.
+func (dp *DNSProvider) Resolve(ctx context.Context) ([]Target, error) {
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	var targets []Target
+	var err error
+
+	if dp.config.Mode == ModeDNSSRV {
+		targets, err = dp.resolveSRV(ctx)
+	} else {
+		targets, err = dp.resolveAddresses(ctx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no records found for %s", dp.config.URL.Hostname())
+	}
+
+	return targets, nil
+}
+
+func (dp *DNSProvider) Run(ctx context.Context, updates chan<- []Target) {
+	for {
+		targets, err := dp.Resolve(ctx)
+
+		if err != nil {
+			log.Printf("❌ DNS discovery for %s failed: %v", dp.config.URL.Hostname(), err)
+		} else {
+			send(ctx, updates, targets)
+		}
+
+		if !sleep(ctx, dp.config.RefreshInterval) {
+			return
+		}
+	}
+}
+
+func (dp *DNSProvider) resolveAddresses(ctx context.Context) ([]Target, error) {
+	addresses, err := dp.resolver.LookupIPAddr(ctx, dp.config.URL.Hostname())
+
+	if err != nil {
+		return nil, err
+	}
+
+	port := dp.config.URL.Port()
+	targets := make([]Target, 0, len(addresses))
+
+	for _, address := range addresses {
+		ip := address.IP.String()
+
+		if address.Zone != "" {
+			ip += "%" + address.Zone
+		}
+
+		hostPort := ip
+
+		if port != "" {
+			hostPort = net.JoinHostPort(ip, port)
+		} else if address.IP.To4() == nil {
+			hostPort = "[" + ip + "]"
+		}
+
+		targets = append(targets, Target{
+			URL:      dp.targetURL(hostPort),
+			Weight:   dp.config.Weight,
+			Priority: dp.config.Priority,
+			Labels:   dp.config.Labels,
+		})
+	}
+
+	return dedupe(targets), nil
+}
+
+func (dp *DNSProvider) resolveSRV(ctx context.Context) ([]Target, error) {
+	_, records, err := dp.resolver.LookupSRV(ctx, "", "", dp.config.URL.Hostname())
+
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]Target, 0, len(records))
+
+	for _, record := range records {
+		if record.Target == "." {
+			continue
+		}
+
+		weight := uint64(record.Weight)
+
+		if weight == 0 {
+			weight = dp.config.Weight
+		}
+
+		host := net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+
+		targets = append(targets, Target{
+			URL:      dp.targetURL(host),
+			Weight:   weight,
+			Priority: int(record.Priority),
+			Labels:   dp.config.Labels,
+		})
+	}
+
+	return dedupe(targets), nil
+}
+
+func (dp *DNSProvider) targetURL(host string) string {
+	target := *dp.config.URL
+	target.Host = host
+	return target.String()
+}
+
+func ValidateMode(mode string) error {
+	switch mode {
+	case ModeDNS, ModeDNSSRV:
+		return nil
+	default:
+		return errors.New("unknown discovery mode: " + mode)
+	}
+}
+
+func dedupe(targets []Target) []Target {
+	slices.SortFunc(targets, func(a, b Target) int {
+		return strings.Compare(a.URL, b.URL)
+	})
+
+	return slices.CompactFunc(targets, func(a, b Target) bool {
+		return a.URL == b.URL
+	})
+}
@@ -0,0 +1,197 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+// stubResolver answers lookups from fixed records.
+type stubResolver struct {
+	addresses map[string][]net.IPAddr
+	srv       map[string][]*net.SRV
+	err       error
+}
+
+func (sr *stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if sr.err != nil {
+		return nil, sr.err
+	}
+
+	return sr.addresses[host], nil
+}
+
+func (sr *stubResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if sr.err != nil {
+		return "", nil, sr.err
+	}
+
+	return name, sr.srv[name], nil
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("invalid URL %s: %v", raw, err)
+	}
+
+	return parsed
+}
+
+func targetURLs(targets []Target) []string {
+	urls := make([]string, 0, len(targets))
+
+	for _, target := range targets {
+		urls = append(urls, target.URL)
+	}
+
+	return urls
+}
+
+func TestDNSProviderResolvesAddresses(t *testing.T) {
+	resolver := &stubResolver{
+		addresses: map[string][]net.IPAddr{
+			"api.internal": {
+				{IP: net.ParseIP("10.0.0.2")},
+				{IP: net.ParseIP("10.0.0.1")},
+				{IP: net.ParseIP("10.0.0.2")},
+				{IP: net.ParseIP("fd00::1")},
+			},
+		},
+	}
+
+	provider := NewDNSProvider(DNSConfig{
+		URL:      mustParseURL(t, "http://api.internal:8080"),
+		Mode:     ModeDNS,
+		Weight:   3,
+		Priority: 1,
+		Labels:   map[string]string{"zone": "a"},
+	}, resolver)
+
+	targets, err := provider.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	want := []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080", "http://[fd00::1]:8080"}
+
+	if got := targetURLs(targets); !slices.Equal(got, want) {
+		t.Fatalf("targets = %v, want %v", got, want)
+	}
+
+	for _, target := range targets {
+		if target.Weight != 3 || target.Priority != 1 || target.Labels["zone"] != "a" {
+			t.Errorf("target %s = %+v, want the entry's weight, priority and labels", target.URL, target)
+		}
+	}
+}
+
+func TestDNSProviderResolvesSRV(t *testing.T) {
+	resolver := &stubResolver{
+		srv: map[string][]*net.SRV{
+			"_api._tcp.example.com": {
+				{Target: "b.example.com.", Port: 9000, Priority: 1, Weight: 0},
+				{Target: "a.example.com.", Port: 8000, Priority: 0, Weight: 5},
+				{Target: ".", Port: 0},
+			},
+		},
+	}
+
+	provider := NewDNSProvider(DNSConfig{
+		URL:    mustParseURL(t, "http://_api._tcp.example.com"),
+		Mode:   ModeDNSSRV,
+		Weight: 2,
+	}, resolver)
+
+	targets, err := provider.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	want := []Target{
+		{URL: "http://a.example.com:8000", Weight: 5, Priority: 0},
+		{URL: "http://b.example.com:9000", Weight: 2, Priority: 1},
+	}
+
+	if len(targets) != len(want) {
+		t.Fatalf("targets = %v, want %v", targetURLs(targets), targetURLs(want))
+	}
+
+	for i, target := range targets {
+		if target.URL != want[i].URL || target.Weight != want[i].Weight || target.Priority != want[i].Priority {
+			t.Errorf("target %d = %+v, want %+v", i, target, want[i])
+		}
+	}
+}
+
+func TestDNSProviderReportsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver *stubResolver
+	}{
+		{name: "lookup error", resolver: &stubResolver{err: errors.New("no such host")}},
+		{name: "no records", resolver: &stubResolver{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewDNSProvider(DNSConfig{
+				URL:  mustParseURL(t, "http://api.internal:8080"),
+				Mode: ModeDNS,
+			}, test.resolver)
+
+			if _, err := provider.Resolve(context.Background()); err == nil {
+				t.Errorf("Resolve succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestDNSProviderRunSendsUpdates(t *testing.T) {
+	resolver := &stubResolver{
+		addresses: map[string][]net.IPAddr{
+			"api.internal": {{IP: net.ParseIP("10.0.0.1")}},
+		},
+	}
+
+	provider := NewDNSProvider(DNSConfig{
+		URL:             mustParseURL(t, "http://api.internal:8080"),
+		Mode:            ModeDNS,
+		RefreshInterval: 10 * time.Millisecond,
+	}, resolver)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan []Target)
+	done := make(chan struct{})
+
+	go func() {
+		provider.Run(ctx, updates)
+		close(done)
+	}()
+
+	// Every refresh reports the full set of targets
+	for range 2 {
+		select {
+		case targets := <-updates:
+			if got := targetURLs(targets); !slices.Equal(got, []string{"http://10.0.0.1:8080"}) {
+				t.Fatalf("targets = %v, want [http://10.0.0.1:8080]", got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no update within 2s")
+		}
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Run did not return after cancel")
+	}
+}
@@ -0,0 +1,259 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TypeFile = "file"
+
+	DefaultFileRefreshInterval = 10 * time.Second
+
+	// schemeLabel overrides the scheme of the targets of a group, as in
+	// Prometheus file_sd
+	schemeLabel = "__scheme__"
+)
+
+type FileConfig struct {
+	// Files lists the target files. Glob patterns are expanded on every
+	// refresh, so files can be added and removed at runtime.
+	Files           []string
+	Scheme          string
+	RefreshInterval time.Duration
+}
+
+// fileHealthCheck mirrors the per-backend health check overrides of the main
+// configuration.
+type fileHealthCheck struct {
+	Path             string        `yaml:"path,omitempty"`
+	Port             int           `yaml:"port,omitempty"`
+	Method           string        `yaml:"method,omitempty"`
+	Interval         time.Duration `yaml:"interval,omitempty"`
+	Timeout          time.Duration `yaml:"timeout,omitempty"`
+	SuccessThreshold int           `yaml:"success_threshold,omitempty"`
+	FailureThreshold int           `yaml:"failure_threshold,omitempty"`
+}
+
+// targetGroup is one entry of a file in the Prometheus file_sd format,
+// extended with a weight, priority and health check overrides for its
+// targets.
+type targetGroup struct {
+	Targets     []string          `yaml:"targets"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Weight      uint64            `yaml:"weight,omitempty"`
+	Priority    int               `yaml:"priority,omitempty"`
+	HealthCheck *fileHealthCheck  `yaml:"health_check,omitempty"`
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// FileProvider reads targets from JSON or YAML files. Files are polled for
+// changes of their modification time or size, and all of them are read again
+// when any changed.
+type FileProvider struct {
+	config FileConfig
+	files  map[string]fileState
+}
+
+func NewFileProvider(config FileConfig) *FileProvider {
+	if config.Scheme == "" {
+		config.Scheme = "http"
+	}
+
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultFileRefreshInterval
+	}
+
+	return &FileProvider{
+		config: config,
+	}
+}
+
+func (fp *FileProvider) Name() string {
+	return TypeFile + ":" + strings.Join(fp.config.Files, ",")
+}
+
+func (fp *FileProvider) matchFiles() ([]string, error) {
+	var files []string
+
+	for _, pattern := range fp.config.Files {
+		matches, err := filepath.Glob(pattern)
+
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, matches...)
+	}
+
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// changed stats the files and reports whether any of them was added,
+// removed or modified since the last refresh.
+func (fp *FileProvider) changed(files []string) (map[string]fileState, bool, error) {
+	states := make(map[string]fileState, len(files))
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		states[file] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	return states, fp.files == nil || !maps.Equal(states, fp.files), nil
+}
+
+// Load reads the files when they changed since the last call. It reports
+// whether they did.
+func (fp *FileProvider) Load() ([]Target, bool, error) {
+	files, err := fp.matchFiles()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	states, changed, err := fp.changed(files)
+
+	if err != nil || !changed {
+		return nil, false, err
+	}
+
+	var targets []Target
+
+	for _, file := range files {
+		fileTargets, err := fp.readFile(file)
+
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", file, err)
+		}
+
+		targets = append(targets, fileTargets...)
+	}
+
+	fp.files = states
+
+	return dedupe(targets), true, nil
+}
+
+func (fp *FileProvider) readFile(file string) ([]Target, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []targetGroup
+
+	// JSON is valid YAML, so both formats go through the same decoder
+	if err := yaml.Unmarshal(data, &groups); err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+
+	for _, group := range groups {
+		scheme := fp.config.Scheme
+		labels := make(map[string]string, len(group.Labels))
+
+		for name, value := range group.Labels {
+			if name == schemeLabel {
+				scheme = value
+				continue
+			}
+
+			// Other reserved labels are dropped, as in Prometheus
+			if !strings.HasPrefix(name, "__") {
+				labels[name] = value
+			}
+		}
+
+		for _, address := range group.Targets {
+			targetURL, err := parseTarget(scheme, address)
+
+			if err != nil {
+				return nil, err
+			}
+
+			targets = append(targets, Target{
+				URL:         targetURL,
+				Weight:      group.Weight,
+				Priority:    group.Priority,
+				Labels:      labels,
+				HealthCheck: group.HealthCheck.override(),
+			})
+		}
+	}
+
+	return targets, nil
+}
+
+// parseTarget accepts a host:port address or a full URL.
+func parseTarget(scheme string, address string) (string, error) {
+	if !strings.Contains(address, "://") {
+		address = scheme + "://" + address
+	}
+
+	targetURL, err := url.Parse(address)
+
+	if err != nil {
+		return "", err
+	}
+
+	if targetURL.Host == "" {
+		return "", fmt.Errorf("invalid target: %s", address)
+	}
+
+	return targetURL.String(), nil
+}
+
+func (fhc *fileHealthCheck) override() *backend.HealthCheckOverride {
+	if fhc == nil {
+		return nil
+	}
+
+	return &backend.HealthCheckOverride{
+		Path:             fhc.Path,
+		Port:             fhc.Port,
+		Method:           fhc.Method,
+		Interval:         fhc.Interval,
+		Timeout:          fhc.Timeout,
+		SuccessThreshold: fhc.SuccessThreshold,
+		FailureThreshold: fhc.FailureThreshold,
+	}
+}
+
+func (fp *FileProvider) Run(ctx context.Context, updates chan<- []Target) {
+	for {
+		targets, changed, err := fp.Load()
+
+		if err != nil {
+			log.Printf("❌ File discovery failed: %v", err)
+		} else if changed {
+			send(ctx, updates, targets)
+		}
+
+		if !sleep(ctx, fp.config.RefreshInterval) {
+			return
+		}
+	}
+}
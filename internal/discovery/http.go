@@ -0,0 +1,387 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/jsonpath"
+)
+
+const (
+	TypeHTTP = "http"
+
+	DefaultHTTPRefreshInterval = 30 * time.Second
+	DefaultHTTPMaxBackoff      = time.Minute
+
+	initialBackoff = time.Second
+
+	// minBlockingInterval keeps a catalog that ignores blocking queries from
+	// being polled in a tight loop
+	minBlockingInterval = time.Second
+
+	httpMaxCatalogSize = 16 * 1024 * 1024
+)
+
+// FieldMapping tells the HTTP provider where to find instance fields in the
+// catalog response. Paths are dotted, e.g. "Service.Address" or
+// "instances.0.host". Items selects the instance list; empty means the
+// response itself is the list.
+type FieldMapping struct {
+	Items    string
+	Address  string
+	Host     string
+	Port     string
+	Scheme   string
+	Weight   string
+	Priority string
+	Labels   map[string]string
+
+	// Healthy, when set, skips instances whose field does not equal
+	// HealthyValue
+	Healthy      string
+	HealthyValue string
+}
+
+type HTTPConfig struct {
+	URL     string
+	Headers map[string]string
+	Mapping FieldMapping
+
+	// Scheme of targets that do not map one
+	Scheme string
+
+	// RefreshInterval is the delay between polls. With IndexHeader set the
+	// provider long-polls instead, passing the last index in IndexParam and
+	// Wait in WaitParam, as in Consul blocking queries.
+	RefreshInterval time.Duration
+	IndexHeader     string
+	IndexParam      string
+	WaitParam       string
+	Wait            time.Duration
+
+	MaxBackoff time.Duration
+}
+
+// HTTPProvider polls a service catalog over HTTP. Responses are cached by
+// ETag, and with a blocking index the catalog holds the request open until
+// the instances change.
+type HTTPProvider struct {
+	config HTTPConfig
+	client *http.Client
+	etag   string
+	index  string
+}
+
+func NewHTTPProvider(config HTTPConfig) *HTTPProvider {
+	if config.Scheme == "" {
+		config.Scheme = "http"
+	}
+
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultHTTPRefreshInterval
+	}
+
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultHTTPMaxBackoff
+	}
+
+	if config.IndexParam == "" {
+		config.IndexParam = "index"
+	}
+
+	if config.WaitParam == "" {
+		config.WaitParam = "wait"
+	}
+
+	if config.Wait <= 0 {
+		config.Wait = 5 * time.Minute
+	}
+
+	return &HTTPProvider{
+		config: config,
+		client: &http.Client{
+			// Leave room for the catalog to answer a blocking query late
+			Timeout: config.Wait + 30*time.Second,
+		},
+	}
+}
+
+func (hp *HTTPProvider) Name() string {
+	return TypeHTTP + ":" + hp.config.URL
+}
+
+func (hp *HTTPProvider) blocking() bool {
+	return hp.config.IndexHeader != ""
+}
+
+func (hp *HTTPProvider) requestURL() (string, error) {
+	requestURL, err := url.Parse(hp.config.URL)
+
+	if err != nil {
+		return "", err
+	}
+
+	if hp.blocking() && hp.index != "" {
+		query := requestURL.Query()
+		query.Set(hp.config.IndexParam, hp.index)
+		query.Set(hp.config.WaitParam, fmt.Sprintf("%ds", int(hp.config.Wait.Seconds())))
+		requestURL.RawQuery = query.Encode()
+	}
+
+	return requestURL.String(), nil
+}
+
+// Fetch queries the catalog once. It reports false when the instances did
+// not change since the last call.
+func (hp *HTTPProvider) Fetch(ctx context.Context) ([]Target, bool, error) {
+	requestURL, err := hp.requestURL()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	for name, value := range hp.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if hp.etag != "" {
+		req.Header.Set("If-None-Match", hp.etag)
+	}
+
+	resp, err := hp.client.Do(req)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("unexpected HTTP status from catalog: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpMaxCatalogSize))
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	targets, err := hp.parse(body)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	hp.etag = resp.Header.Get("ETag")
+
+	if hp.blocking() {
+		index := resp.Header.Get(hp.config.IndexHeader)
+
+		// An index that goes backwards means the catalog was reset
+		if previous, err := strconv.ParseUint(hp.index, 10, 64); err == nil {
+			if current, err := strconv.ParseUint(index, 10, 64); err == nil && current < previous {
+				index = ""
+			}
+		}
+
+		hp.index = index
+	}
+
+	return targets, true, nil
+}
+
+func (hp *HTTPProvider) parse(body []byte) ([]Target, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var document any
+
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid catalog response: %w", err)
+	}
+
+	items, found := lookupField(document, hp.config.Mapping.Items)
+	instances, isList := items.([]any)
+
+	if !found || !isList {
+		return nil, fmt.Errorf("catalog response has no instance list at %q", hp.config.Mapping.Items)
+	}
+
+	targets := make([]Target, 0, len(instances))
+
+	for _, instance := range instances {
+		target, ok, err := hp.mapInstance(instance)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			targets = append(targets, target)
+		}
+	}
+
+	return dedupe(targets), nil
+}
+
+// mapInstance builds the target of one catalog instance. It returns false for
+// instances that are not healthy or have no address, which are skipped
+// without rejecting the rest of the catalog.
+func (hp *HTTPProvider) mapInstance(instance any) (Target, bool, error) {
+	mapping := hp.config.Mapping
+
+	if mapping.Healthy != "" && fieldString(instance, mapping.Healthy) != mapping.HealthyValue {
+		return Target{}, false, nil
+	}
+
+	address := fieldString(instance, mapping.Address)
+
+	if address == "" {
+		host := fieldString(instance, mapping.Host)
+		port := fieldString(instance, mapping.Port)
+
+		if host == "" {
+			log.Printf("⚠️ HTTP discovery from %s: skipping catalog instance without an address", hp.config.URL)
+			return Target{}, false, nil
+		}
+
+		address = host
+
+		if port != "" {
+			address = net.JoinHostPort(host, port)
+		}
+	}
+
+	scheme := hp.config.Scheme
+
+	if mappedScheme := fieldString(instance, mapping.Scheme); mappedScheme != "" {
+		scheme = mappedScheme
+	}
+
+	targetURL, err := parseTarget(scheme, address)
+
+	if err != nil {
+		return Target{}, false, err
+	}
+
+	target := Target{URL: targetURL}
+
+	if weight := fieldString(instance, mapping.Weight); weight != "" {
+		target.Weight, err = strconv.ParseUint(weight, 10, 64)
+
+		if err != nil {
+			return Target{}, false, fmt.Errorf("invalid weight for %s: %s", targetURL, weight)
+		}
+	}
+
+	if priority := fieldString(instance, mapping.Priority); priority != "" {
+		target.Priority, err = strconv.Atoi(priority)
+
+		if err != nil {
+			return Target{}, false, fmt.Errorf("invalid priority for %s: %s", targetURL, priority)
+		}
+	}
+
+	if len(mapping.Labels) > 0 {
+		target.Labels = make(map[string]string, len(mapping.Labels))
+
+		for name, path := range mapping.Labels {
+			if value := fieldString(instance, path); value != "" {
+				target.Labels[name] = value
+			}
+		}
+	}
+
+	return target, true, nil
+}
+
+func (hp *HTTPProvider) Run(ctx context.Context, updates chan<- []Target) {
+	backoff := time.Duration(0)
+
+	for {
+		started := time.Now()
+		targets, changed, err := hp.Fetch(ctx)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		delay := hp.config.RefreshInterval
+
+		if err != nil {
+			backoff = min(max(backoff*2, initialBackoff), hp.config.MaxBackoff)
+			delay = backoff
+
+			log.Printf("❌ HTTP discovery from %s failed, retrying in %s: %v", hp.config.URL, delay, err)
+		} else {
+			backoff = 0
+
+			if changed {
+				send(ctx, updates, targets)
+			}
+
+			if hp.blocking() {
+				delay = max(0, minBlockingInterval-time.Since(started))
+			}
+		}
+
+		if !sleep(ctx, delay) {
+			return
+		}
+	}
+}
+
+// lookupField follows a dotted path through decoded JSON. An empty path
+// returns the value itself.
+func lookupField(value any, path string) (any, bool) {
+	if path == "" {
+		return value, true
+	}
+
+	return jsonpath.Lookup(value, strings.Split(path, "."))
+}
+
+// fieldString returns a scalar field as a string, or "" when the path is
+// empty or missing.
+func fieldString(value any, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	field, found := lookupField(value, path)
+
+	if !found || field == nil {
+		return ""
+	}
+
+	switch v := field.(type) {
+	case string:
+		return v
+	case json.Number, bool:
+		return fmt.Sprint(v)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,177 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+const consulCatalog = `[
+	{"Service": {"Address": "10.0.0.2", "Port": 8080, "Weights": {"Passing": 5}, "Meta": {"zone": "a"}}, "Status": "passing"},
+	{"Service": {"Address": "10.0.0.1", "Port": 8080, "Weights": {"Passing": 1}, "Meta": {"zone": "b"}}, "Status": "passing"},
+	{"Service": {"Address": "10.0.0.3", "Port": 8080}, "Status": "critical"},
+	{"Service": {"Port": 8080}, "Status": "passing"}
+]`
+
+var consulMapping = FieldMapping{
+	Host:         "Service.Address",
+	Port:         "Service.Port",
+	Weight:       "Service.Weights.Passing",
+	Labels:       map[string]string{"zone": "Service.Meta.zone"},
+	Healthy:      "Status",
+	HealthyValue: "passing",
+}
+
+// catalogServer serves body with etag, answering 304 to requests that already
+// have it, and records the query of every request.
+type catalogServer struct {
+	*httptest.Server
+	body    string
+	etag    string
+	index   string
+	mutex   sync.Mutex
+	queries []string
+}
+
+func startCatalogServer(t *testing.T, body string, etag string, index string) *catalogServer {
+	t.Helper()
+
+	cs := &catalogServer{body: body, etag: etag, index: index}
+
+	cs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cs.mutex.Lock()
+		cs.queries = append(cs.queries, r.URL.RawQuery)
+		cs.mutex.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if cs.etag != "" && r.Header.Get("If-None-Match") == cs.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		if cs.etag != "" {
+			w.Header().Set("ETag", cs.etag)
+		}
+
+		if cs.index != "" {
+			w.Header().Set("X-Consul-Index", cs.index)
+		}
+
+		w.Write([]byte(cs.body))
+	}))
+
+	t.Cleanup(cs.Close)
+
+	return cs
+}
+
+func TestHTTPProviderMapsInstances(t *testing.T) {
+	server := startCatalogServer(t, consulCatalog, "", "")
+
+	provider := NewHTTPProvider(HTTPConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		Mapping: consulMapping,
+	})
+
+	targets, changed, err := provider.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	if !changed {
+		t.Errorf("changed = false on the first fetch, want true")
+	}
+
+	// The critical instance and the one without an address are skipped
+	want := []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"}
+
+	if got := targetURLs(targets); !slices.Equal(got, want) {
+		t.Fatalf("targets = %v, want %v", got, want)
+	}
+
+	if targets[1].Weight != 5 || targets[1].Labels["zone"] != "a" {
+		t.Errorf("target %s = %+v, want weight 5 and zone a", targets[1].URL, targets[1])
+	}
+}
+
+func TestHTTPProviderRevalidatesWithETag(t *testing.T) {
+	server := startCatalogServer(t, consulCatalog, `"v1"`, "")
+
+	provider := NewHTTPProvider(HTTPConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		Mapping: consulMapping,
+	})
+
+	if _, changed, err := provider.Fetch(context.Background()); err != nil || !changed {
+		t.Fatalf("first Fetch = changed %t, error %v, want changed", changed, err)
+	}
+
+	targets, changed, err := provider.Fetch(context.Background())
+
+	if err != nil || changed || targets != nil {
+		t.Errorf("second Fetch = %v, changed %t, error %v, want no change", targets, changed, err)
+	}
+}
+
+func TestHTTPProviderSendsBlockingIndex(t *testing.T) {
+	server := startCatalogServer(t, consulCatalog, "", "42")
+
+	provider := NewHTTPProvider(HTTPConfig{
+		URL:         server.URL + "/v1/health/service/api",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		Mapping:     consulMapping,
+		IndexHeader: "X-Consul-Index",
+	})
+
+	for range 2 {
+		if _, _, err := provider.Fetch(context.Background()); err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if len(server.queries) != 2 || server.queries[0] != "" || server.queries[1] != "index=42&wait=300s" {
+		t.Errorf("queries = %q, want no index and then index=42&wait=300s", server.queries)
+	}
+}
+
+func TestHTTPProviderRejectsBadResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		mapping FieldMapping
+	}{
+		{name: "error status", body: consulCatalog, mapping: consulMapping},
+		{name: "invalid JSON", body: "{", headers: map[string]string{"Authorization": "Bearer token"}, mapping: consulMapping},
+		{name: "no instance list", body: `{"instances": {}}`, headers: map[string]string{"Authorization": "Bearer token"}, mapping: FieldMapping{Items: "instances", Address: "address"}},
+		{name: "invalid weight", body: `[{"address": "10.0.0.1:80", "weight": "heavy"}]`, headers: map[string]string{"Authorization": "Bearer token"}, mapping: FieldMapping{Address: "address", Weight: "weight"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := startCatalogServer(t, test.body, "", "")
+
+			provider := NewHTTPProvider(HTTPConfig{
+				URL:     server.URL,
+				Headers: test.headers,
+				Mapping: test.mapping,
+			})
+
+			if _, _, err := provider.Fetch(context.Background()); err == nil {
+				t.Errorf("Fetch succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// initialSyncTimeout bounds how long Start waits for the first targets of a
+// provider before serving traffic without them.
+const initialSyncTimeout = 5 * time.Second
+
+// Provider reports the targets of a pool. Run sends the complete current set
+// of targets on updates whenever it may have changed, and returns when ctx is
+// cancelled. Providers handle their own scheduling and retries; on errors
+// they log and keep the last reported set by not sending anything.
+type Provider interface {
+	Name() string
+	Run(ctx context.Context, updates chan<- []Target)
+}
+
+// Discovery applies the updates of a provider to the pool through a Syncer.
+type Discovery struct {
+	provider Provider
+	syncer   *Syncer
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// Start runs a provider in the background. It returns once the first targets
+// have been applied, or after initialSyncTimeout, so the pool is populated
+// before traffic arrives.
+func Start(provider Provider, syncer *Syncer) *Discovery {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := &Discovery{
+		provider: provider,
+		syncer:   syncer,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	updates := make(chan []Target)
+	synced := make(chan struct{})
+
+	go func() {
+		provider.Run(ctx, updates)
+		close(updates)
+	}()
+
+	go func() {
+		defer close(d.done)
+
+		first := true
+
+		for targets := range updates {
+			syncer.Sync(targets)
+
+			if first {
+				first = false
+				close(synced)
+			}
+		}
+	}()
+
+	select {
+	case <-synced:
+	case <-time.After(initialSyncTimeout):
+		log.Printf("⚠️ Discovery %s: no targets after %s, continuing in the background", provider.Name(), initialSyncTimeout)
+	}
+
+	return d
+}
+
+func (d *Discovery) Stop() {
+	d.cancel()
+	<-d.done
+}
+
+// send delivers targets unless ctx is cancelled first.
+func send(ctx context.Context, updates chan<- []Target, targets []Target) {
+	select {
+	case updates <- targets:
+	case <-ctx.Done():
+	}
+}
+
+// sleep waits for delay and reports whether ctx is still active.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
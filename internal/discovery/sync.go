@@ -0,0 +1,120 @@
+package discovery
+
+import (
+	"log"
+	"maps"
+	"sync"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+)
+
+// Target is a backend reported by a discovery provider.
+type Target struct {
+	URL         string
+	Weight      uint64
+	Priority    int
+	Labels      map[string]string
+	HealthCheck *backend.HealthCheckOverride
+}
+
+// BackendFactory creates the backend for a newly discovered target, applying
+// the settings of the pool it belongs to.
+type BackendFactory func(target Target) (*backend.Backend, error)
+
+// Syncer keeps the backends of a load balancer in line with the targets of
+// one provider. It applies the difference to the previous set of targets, so
+// backends that stay keep their counters and strategy state. Backends added
+// by other providers or by the static configuration are never touched.
+type Syncer struct {
+	name         string
+	loadBalancer *loadbalancer.LoadBalancer
+	factory      BackendFactory
+	backends     map[string]*backend.Backend
+	targets      map[string]Target
+	mutex        sync.Mutex
+}
+
+func NewSyncer(name string, loadBalancer *loadbalancer.LoadBalancer, factory BackendFactory) *Syncer {
+	return &Syncer{
+		name:         name,
+		loadBalancer: loadBalancer,
+		factory:      factory,
+		backends:     make(map[string]*backend.Backend),
+		targets:      make(map[string]Target),
+	}
+}
+
+func (s *Syncer) Sync(targets []Target) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	seen := make(map[string]bool, len(targets))
+
+	for _, target := range targets {
+		if target.Weight == 0 {
+			target.Weight = 1
+		}
+
+		seen[target.URL] = true
+
+		if existing, exists := s.backends[target.URL]; exists {
+			previous := s.targets[target.URL]
+
+			if previous.Priority == target.Priority && sameHealthCheck(previous.HealthCheck, target.HealthCheck) {
+				s.update(existing, target)
+				s.targets[target.URL] = target
+				continue
+			}
+
+			// A backend's priority and health check cannot change in place
+			delete(s.backends, target.URL)
+			delete(s.targets, target.URL)
+			s.loadBalancer.RemoveBackend(existing)
+		}
+
+		newBackend, err := s.factory(target)
+
+		if err != nil {
+			log.Printf("❌ Discovery %s: invalid target %s: %v", s.name, target.URL, err)
+			continue
+		}
+
+		s.backends[target.URL] = newBackend
+		s.targets[target.URL] = target
+		s.loadBalancer.AddBackend(newBackend)
+
+		log.Printf("✅ Discovery %s: added backend %s (weight: %d)", s.name, target.URL, target.Weight)
+	}
+
+	for targetURL, existing := range s.backends {
+		if seen[targetURL] {
+			continue
+		}
+
+		delete(s.backends, targetURL)
+		delete(s.targets, targetURL)
+		s.loadBalancer.RemoveBackend(existing)
+
+		log.Printf("🗑️ Discovery %s: removed backend %s", s.name, targetURL)
+	}
+}
+
+func (s *Syncer) update(existing *backend.Backend, target Target) {
+	if existing.GetWeight() != target.Weight {
+		s.loadBalancer.SetBackendWeight(existing, target.Weight)
+		log.Printf("⚖️ Discovery %s: updated weight of %s to %d", s.name, target.URL, target.Weight)
+	}
+
+	if !maps.Equal(existing.GetLabels(), target.Labels) {
+		existing.SetLabels(target.Labels)
+	}
+}
+
+func sameHealthCheck(a, b *backend.HealthCheckOverride) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
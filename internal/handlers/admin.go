@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/health"
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+	"github.com/franciscodelahoz/load-balancer/internal/split"
+)
+
+type AdminHandler struct {
+	mux *http.ServeMux
+}
+
+type backendHealthView struct {
+	URL       string    `json:"url"`
+	Alive     bool      `json:"alive"`
+	Status    string    `json:"status"`
+	Latency   string    `json:"latency,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at,omitzero"`
+}
+
+type healthCheckView struct {
+	Status    string    `json:"status"`
+	Latency   string    `json:"latency"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type backendHistoryView struct {
+	URL           string            `json:"url"`
+	Checks        int               `json:"checks"`
+	SuccessRatio  float64           `json:"success_ratio"`
+	LatencyP50    string            `json:"latency_p50"`
+	LatencyP90    string            `json:"latency_p90"`
+	LatencyP99    string            `json:"latency_p99"`
+	FlappingUntil time.Time         `json:"flapping_until,omitzero"`
+	Results       []healthCheckView `json:"results"`
+}
+
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{
+		mux: http.NewServeMux(),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("❌ Error encoding admin response: %v", err)
+	}
+}
+
+func (ah *AdminHandler) Handle(pattern string, handler http.Handler) {
+	ah.mux.Handle(pattern, handler)
+}
+
+// HandleJSON serves the value returned by provider as JSON on GET requests.
+func (ah *AdminHandler) HandleJSON(path string, provider func() any) {
+	ah.mux.HandleFunc("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, provider())
+	})
+}
+
+func (ah *AdminHandler) RegisterLoadBalancers(loadBalancers map[string]*loadbalancer.LoadBalancer) {
+	ah.HandleJSON("/admin/stats", func() any {
+		pools := make(map[string]*loadbalancer.Stats, len(loadBalancers))
+
+		for name, lb := range loadBalancers {
+			pools[name] = lb.GetStats()
+		}
+
+		return pools
+	})
+
+	ah.HandleJSON("/admin/health", func() any {
+		pools := make(map[string][]backendHealthView, len(loadBalancers))
+
+		for name, lb := range loadBalancers {
+			results := lb.GetHealthResults()
+			views := make([]backendHealthView, 0)
+
+			for _, b := range lb.GetAllBackends() {
+				view := backendHealthView{
+					URL:    b.URL.String(),
+					Alive:  b.IsAlive(),
+					Status: health.StatusUnknown.String(),
+				}
+
+				if result, exists := results[b.URL.String()]; exists {
+					view.Status = result.Status.String()
+					view.Latency = result.Latency.String()
+					view.CheckedAt = result.CheckedAt
+
+					if result.Error != nil {
+						view.Error = result.Error.Error()
+					}
+				}
+
+				views = append(views, view)
+			}
+
+			pools[name] = views
+		}
+
+		return pools
+	})
+
+	ah.HandleJSON("/admin/health/history", func() any {
+		pools := make(map[string][]backendHistoryView, len(loadBalancers))
+
+		for name, lb := range loadBalancers {
+			histories := lb.GetHealthHistory()
+			views := make([]backendHistoryView, 0, len(histories))
+
+			for _, b := range lb.GetAllBackends() {
+				if history, exists := histories[b.URL.String()]; exists {
+					views = append(views, newBackendHistoryView(b.URL.String(), history))
+				}
+			}
+
+			pools[name] = views
+		}
+
+		return pools
+	})
+}
+
+type splitWeightsRequest struct {
+	Weights map[string]uint64 `json:"weights"`
+}
+
+// RegisterSplits serves the traffic splits of the routes, and lets their
+// weights be changed with PUT /admin/splits/{name}.
+func (ah *AdminHandler) RegisterSplits(splits map[string]*split.Split) {
+	ah.HandleJSON("/admin/splits", func() any {
+		snapshots := make(map[string]*split.Snapshot, len(splits))
+
+		for name, s := range splits {
+			snapshots[name] = s.Snapshot()
+		}
+
+		return snapshots
+	})
+
+	ah.mux.HandleFunc("PUT /admin/splits/{name}", func(w http.ResponseWriter, r *http.Request) {
+		s, exists := splits[r.PathValue("name")]
+
+		if !exists {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown split"})
+			return
+		}
+
+		var request splitWeightsRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+
+		if err := s.SetWeights(request.Weights); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, s.Snapshot())
+	})
+}
+
+func newBackendHistoryView(url string, history *health.BackendHistory) backendHistoryView {
+	view := backendHistoryView{
+		URL:          url,
+		Checks:       history.Summary.Checks,
+		SuccessRatio: history.Summary.SuccessRatio,
+		LatencyP50:   history.Summary.LatencyP50.String(),
+		LatencyP90:   history.Summary.LatencyP90.String(),
+		LatencyP99:   history.Summary.LatencyP99.String(),
+		Results:      make([]healthCheckView, 0, len(history.Results)),
+	}
+
+	if time.Now().Before(history.FlappingUntil) {
+		view.FlappingUntil = history.FlappingUntil
+	}
+
+	for _, result := range history.Results {
+		check := healthCheckView{
+			Status:    result.Status.String(),
+			Latency:   result.Latency.String(),
+			CheckedAt: result.CheckedAt,
+		}
+
+		if result.Error != nil {
+			check.Error = result.Error.Error()
+		}
+
+		view.Results = append(view.Results, check)
+	}
+
+	return view
+}
+
+func (ah *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ah.mux.ServeHTTP(w, r)
+}
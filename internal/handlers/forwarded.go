@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/franciscodelahoz/load-balancer/internal/clientip"
+)
+
+func formatForwardedNode(ip string) string {
+	// RFC 7239 requires IPv6 addresses to be bracketed and quoted
+	if strings.Contains(ip, ":") {
+		return fmt.Sprintf("\"[%s]\"", ip)
+	}
+
+	return ip
+}
+
+// quoteString formats value as an RFC 7230 quoted-string. Quotes and
+// backslashes are escaped, and control characters, which a quoted-string
+// cannot hold, are dropped.
+func quoteString(value string) string {
+	var quoted strings.Builder
+
+	quoted.WriteByte('"')
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		switch {
+		case c == '"' || c == '\\':
+			quoted.WriteByte('\\')
+			quoted.WriteByte(c)
+		case c == '\t' || (c >= 0x20 && c != 0x7f):
+			quoted.WriteByte(c)
+		}
+	}
+
+	quoted.WriteByte('"')
+
+	return quoted.String()
+}
+
+func forwardedElement(r *http.Request, info *clientip.Info) string {
+	return fmt.Sprintf("for=%s;host=%s;proto=%s", formatForwardedNode(info.PeerIP), quoteString(r.Host), getScheme(r))
+}
+
+// setForwardingHeaders prepares X-Forwarded-For, X-Forwarded-Host,
+// X-Forwarded-Proto, Forwarded and X-Real-IP on the outgoing request.
+// Forwarding headers are only kept when the direct peer is a trusted proxy,
+// otherwise they are replaced. ReverseProxy itself appends the peer address
+// to X-Forwarded-For after the Director runs.
+func setForwardingHeaders(req *http.Request, r *http.Request, info *clientip.Info, emitForwarded bool) {
+	if !info.TrustedPeer {
+		req.Header.Del("X-Forwarded-For")
+		req.Header.Del("X-Forwarded-Host")
+		req.Header.Del("X-Forwarded-Proto")
+		req.Header.Del("Forwarded")
+	}
+
+	// A trusted proxy saw the original host and scheme, so its values win
+	if req.Header.Get("X-Forwarded-Host") == "" {
+		req.Header.Set("X-Forwarded-Host", r.Host)
+	}
+
+	if req.Header.Get("X-Forwarded-Proto") == "" {
+		req.Header.Set("X-Forwarded-Proto", getScheme(r))
+	}
+
+	req.Header.Set("X-Real-IP", info.ClientIP)
+
+	if !emitForwarded {
+		return
+	}
+
+	element := forwardedElement(r, info)
+
+	if prior := req.Header.Values("Forwarded"); len(prior) > 0 {
+		element = strings.Join(prior, ", ") + ", " + element
+	}
+
+	req.Header.Set("Forwarded", element)
+}
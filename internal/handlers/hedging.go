@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/hedge"
+)
+
+type servedByKey struct{}
+
+// servedBy returns the backend an outgoing request was sent to, which
+// differs from the selected backend when a hedged request won.
+func servedBy(req *http.Request, selected *backend.Backend) *backend.Backend {
+	if req == nil {
+		return selected
+	}
+
+	if b, ok := req.Context().Value(servedByKey{}).(*backend.Backend); ok {
+		return b
+	}
+
+	return selected
+}
+
+func backendTransport(b *backend.Backend) http.RoundTripper {
+	if b.ReverseProxy.Transport != nil {
+		return b.ReverseProxy.Transport
+	}
+
+	return http.DefaultTransport
+}
+
+type attempt struct {
+	backend *backend.Backend
+	hedged  bool
+	resp    *http.Response
+	err     error
+	cancel  context.CancelFunc
+	start   time.Time
+}
+
+// hedgingTransport sends the request to the selected backend and, when no
+// response arrived within the policy's delay, the same request to a second
+// backend chosen by the strategy. The first response wins and the other
+// request is cancelled.
+type hedgingTransport struct {
+	handler *ProxyHandler
+	policy  *hedge.Policy
+	inbound *http.Request
+	primary *backend.Backend
+
+	// failedBackend is the backend whose error RoundTrip returned. Its error
+	// is counted by the proxy's ErrorHandler, the others by RoundTrip.
+	failedBackend *backend.Backend
+}
+
+func (ht *hedgingTransport) send(req *http.Request, b *backend.Backend, hedged bool, results chan<- *attempt) *attempt {
+	ctx, cancel := context.WithCancel(context.WithValue(req.Context(), servedByKey{}, b))
+
+	a := &attempt{
+		backend: b,
+		hedged:  hedged,
+		cancel:  cancel,
+		start:   time.Now(),
+	}
+
+	outreq := req.WithContext(ctx)
+
+	go func() {
+		a.resp, a.err = backendTransport(b).RoundTrip(outreq)
+		results <- a
+	}()
+
+	return a
+}
+
+// startHedge picks the second backend through the pool's strategy, skipping
+// the selected one, and sends the hedged request. It returns nil when no
+// backend is available or the hedge ratio is reached.
+func (ht *hedgingTransport) startHedge(req *http.Request, results chan<- *attempt) *attempt {
+	loadBalancer := ht.handler.loadBalancer
+	hedgeBackend := loadBalancer.GetNextBackendExcluding(ht.inbound, map[*backend.Backend]bool{ht.primary: true})
+
+	if hedgeBackend == nil {
+		return nil
+	}
+
+	limiter := hedgeBackend.ConcurrencyLimiter
+
+	if limiter != nil && !limiter.TryAcquire() {
+		loadBalancer.OnRequestCompleted(hedgeBackend)
+		return nil
+	}
+
+	if !ht.policy.Acquire() {
+		if limiter != nil {
+			limiter.Release(0, false)
+		}
+
+		loadBalancer.OnRequestCompleted(hedgeBackend)
+		return nil
+	}
+
+	hedgeReq := req.Clone(req.Context())
+	inboundURL := *ht.inbound.URL
+	hedgeReq.URL = &inboundURL
+
+	hedgeBackend.ReverseProxy.Director(hedgeReq)
+	hedgeReq.Host = hedgeBackend.URL.Host
+
+	hedgeBackend.IncrementRequestsCount()
+
+	log.Printf("🏇 Hedging %s %s -> %s", ht.inbound.Method, ht.inbound.URL.Path, hedgeBackend.URL.String())
+
+	return ht.send(hedgeReq, hedgeBackend, true, results)
+}
+
+// finish releases a hedged attempt once its response is consumed or
+// discarded. The selected backend is released by the proxy handler.
+func (ht *hedgingTransport) finish(a *attempt, failed bool) {
+	a.cancel()
+
+	if !a.hedged {
+		return
+	}
+
+	if limiter := a.backend.ConcurrencyLimiter; limiter != nil {
+		limiter.Release(time.Since(a.start), failed)
+	}
+
+	ht.handler.loadBalancer.OnRequestCompleted(a.backend)
+}
+
+// discard drops the response of the attempt that lost.
+func (ht *hedgingTransport) discard(a *attempt) {
+	if a.resp != nil {
+		a.resp.Body.Close()
+	}
+
+	ht.finish(a, false)
+}
+
+func (ht *hedgingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ht.policy.Start()
+
+	results := make(chan *attempt, 2)
+	attempts := []*attempt{ht.send(req, ht.primary, false, results)}
+
+	timer := time.NewTimer(ht.policy.Delay())
+	defer timer.Stop()
+
+	var winner *attempt
+
+	select {
+	case winner = <-results:
+	case <-timer.C:
+		if hedged := ht.startHedge(req, results); hedged != nil {
+			attempts = append(attempts, hedged)
+		}
+
+		winner = <-results
+	}
+
+	primary := attempts[0]
+	primaryFailed := false
+	pending := len(attempts) - 1
+
+	// A failed attempt is no response, so the other one is awaited
+	if winner.err != nil && pending > 0 {
+		failed := winner
+		primaryFailed = failed == primary
+		winner = <-results
+		pending -= 1
+
+		if !errors.Is(failed.err, context.Canceled) {
+			failed.backend.IncrementErrorCount()
+		}
+
+		ht.finish(failed, true)
+	}
+
+	for _, a := range attempts {
+		if a != winner {
+			a.cancel()
+		}
+	}
+
+	if pending > 0 {
+		go func() {
+			ht.discard(<-results)
+		}()
+	}
+
+	if winner.err != nil {
+		ht.failedBackend = winner.backend
+		ht.finish(winner, true)
+
+		return nil, winner.err
+	}
+
+	// Only the selected backend's latency drives the delay, or hedges that
+	// win would pull the percentile down and trigger ever more hedging. When
+	// the hedge won, the primary took at least until now.
+	if !primaryFailed {
+		ht.policy.Observe(time.Since(primary.start))
+	}
+
+	if winner.hedged {
+		ht.policy.RecordHedgeWin()
+	}
+
+	winner.resp.Body = &hedgedBody{
+		ReadCloser: winner.resp.Body,
+		done: func() {
+			ht.finish(winner, winner.resp.StatusCode >= 500)
+		},
+	}
+
+	return winner.resp, nil
+}
+
+// hedgedBody releases the winning attempt when the proxy is done with the
+// response.
+type hedgedBody struct {
+	io.ReadCloser
+	done func()
+	once sync.Once
+}
+
+func (hb *hedgedBody) Close() error {
+	err := hb.ReadCloser.Close()
+	hb.once.Do(hb.done)
+
+	return err
+}
@@ -2,14 +2,24 @@ package handlers
 
 import (
 	"log"
+	"net"
 	"net/http"
+	"net/http/httputil"
+	"time"
 
 	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/clientip"
+	"github.com/franciscodelahoz/load-balancer/internal/headers"
+	"github.com/franciscodelahoz/load-balancer/internal/hedge"
 	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+	"github.com/franciscodelahoz/load-balancer/internal/proxyproto"
 )
 
 type ProxyHandler struct {
-	loadBalancer *loadbalancer.LoadBalancer
+	loadBalancer    *loadbalancer.LoadBalancer
+	headerRules     []*headers.Rules
+	forwardedHeader bool
+	hedging         *hedge.Policy
 }
 
 func NewProxyHandler(lb *loadbalancer.LoadBalancer) *ProxyHandler {
@@ -18,6 +28,21 @@ func NewProxyHandler(lb *loadbalancer.LoadBalancer) *ProxyHandler {
 	}
 }
 
+// SetHeaderRules replaces the header rules of the handler. Rules are applied
+// in the given order, so later rule sets win over earlier ones.
+func (ph *ProxyHandler) SetHeaderRules(rules ...*headers.Rules) {
+	ph.headerRules = rules
+}
+
+func (ph *ProxyHandler) SetForwardedHeader(enabled bool) {
+	ph.forwardedHeader = enabled
+}
+
+// SetHedging enables hedged requests for the requests the policy accepts.
+func (ph *ProxyHandler) SetHedging(policy *hedge.Policy) {
+	ph.hedging = policy
+}
+
 func getScheme(r *http.Request) string {
 	if r.TLS != nil {
 		return "https"
@@ -26,11 +51,40 @@ func getScheme(r *http.Request) string {
 	return "http"
 }
 
+// selectBackend asks the strategy for a backend until one accepts the request
+// under its concurrency limit. The second value reports whether a backend was
+// skipped because it was at its limit.
+func (ph *ProxyHandler) selectBackend(r *http.Request) (*backend.Backend, bool) {
+	tried := make(map[*backend.Backend]bool)
+
+	for {
+		selectedBackend := ph.loadBalancer.GetNextBackendExcluding(r, tried)
+
+		if selectedBackend == nil {
+			return nil, len(tried) > 0
+		}
+
+		limiter := selectedBackend.ConcurrencyLimiter
+
+		if limiter == nil || limiter.TryAcquire() {
+			return selectedBackend, false
+		}
+
+		tried[selectedBackend] = true
+		ph.loadBalancer.OnRequestCompleted(selectedBackend)
+	}
+}
+
 func (ph *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	selectedBackend := ph.loadBalancer.GetNextBackend(r)
+	selectedBackend, limited := ph.selectBackend(r)
 
 	if selectedBackend == nil {
-		log.Printf("❌ No healthy backends available")
+		if limited {
+			log.Printf("⏳ All backends are at their concurrency limit")
+		} else {
+			log.Printf("❌ No healthy backends available")
+		}
+
 		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
 		return
 	}
@@ -43,40 +97,113 @@ func (ph *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ph.loadBalancer.OnRequestCompleted(selectedBackend)
 	}()
 
-	log.Printf("🎯 %s -> %s", r.URL.Path, selectedBackend.URL.String())
+	log.Printf("🎯 %s %s -> %s", clientip.ClientIP(r), r.URL.Path, selectedBackend.URL.String())
 
-	ph.proxyRequest(w, r, selectedBackend)
+	if selectedBackend.ProxyProtocol != "" {
+		r = r.WithContext(proxyproto.WithSourceAddr(r.Context(), remoteAddr(r)))
+	}
+
+	start := time.Now()
+	failed := ph.proxyRequest(w, r, selectedBackend)
+
+	if limiter := selectedBackend.ConcurrencyLimiter; limiter != nil {
+		limiter.Release(time.Since(start), failed)
+	}
 }
 
-func (ph *ProxyHandler) proxyRequest(w http.ResponseWriter, r *http.Request, b *backend.Backend) {
-	proxy := b.ReverseProxy
+func remoteAddr(r *http.Request) net.Addr {
+	addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
 
-	originalDirector := proxy.Director
+	if err != nil {
+		return nil
+	}
 
-	proxy.Director = func(req *http.Request) {
-		originalDirector(req)
+	return addr
+}
+
+func (ph *ProxyHandler) headerValues(r *http.Request, b *backend.Backend) *headers.Values {
+	return &headers.Values{
+		ClientIP:   clientip.ClientIP(r),
+		BackendURL: b.URL.String(),
+		Host:       r.Host,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Scheme:     getScheme(r),
+		RequestID: func() string {
+			return getRequestID(r)
+		},
+	}
+}
+
+func (ph *ProxyHandler) proxyRequest(w http.ResponseWriter, r *http.Request, b *backend.Backend) bool {
+	failed := false
+
+	values := ph.headerValues(r, b)
+	clientInfo := clientip.FromRequest(r)
+
+	// The backend's ReverseProxy is shared between concurrent requests, so the
+	// per-request hooks are set on a copy instead of on the shared instance.
+	proxy := &httputil.ReverseProxy{
+		Transport: b.ReverseProxy.Transport,
+	}
+
+	var hedging *hedgingTransport
+
+	if ph.hedging != nil && ph.hedging.Eligible(r) {
+		hedging = &hedgingTransport{
+			handler: ph,
+			policy:  ph.hedging,
+			inbound: r,
+			primary: b,
+		}
+
+		proxy.Transport = hedging
+	}
 
-		originalHost := req.Host
+	proxy.Director = func(req *http.Request) {
+		b.ReverseProxy.Director(req)
 		req.Host = b.URL.Host
 
-		req.Header.Set("X-Forwarded-Host", originalHost)
-		req.Header.Set("X-Forwarded-Proto", getScheme(r))
+		setForwardingHeaders(req, r, clientInfo, ph.forwardedHeader)
+
+		for _, rules := range ph.headerRules {
+			rules.Request.Apply(req.Header, values)
+		}
 	}
 
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-		log.Printf("❌ Proxy error for backend %s: %v", b.URL.String(), err)
+		failedBackend := b
+
+		// When hedged, the error may come from the second backend
+		if hedging != nil && hedging.failedBackend != nil {
+			failedBackend = hedging.failedBackend
+		}
+
+		log.Printf("❌ Proxy error for backend %s: %v", failedBackend.URL.String(), err)
 
-		b.IncrementErrorCount()
+		failedBackend.IncrementErrorCount()
+		failed = true
 
 		http.Error(w, "Bad Gateway", http.StatusBadGateway)
 	}
 
 	proxy.ModifyResponse = func(resp *http.Response) error {
 		if resp.StatusCode >= 400 {
-			b.IncrementErrorCount()
+			servedBy(resp.Request, b).IncrementErrorCount()
 		}
+
+		if resp.StatusCode >= 500 {
+			failed = true
+		}
+
+		for _, rules := range ph.headerRules {
+			rules.Response.Apply(resp.Header, values)
+		}
+
 		return nil
 	}
 
 	proxy.ServeHTTP(w, r)
+
+	return failed
 }
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+)
+
+type poolReadinessView struct {
+	Healthy int  `json:"healthy"`
+	Total   int  `json:"total"`
+	Ready   bool `json:"ready"`
+}
+
+type readinessView struct {
+	Ready bool                         `json:"ready"`
+	Pools map[string]poolReadinessView `json:"pools"`
+}
+
+// ReadinessHandler reports the balancer as ready once every pool has at least
+// minHealthy healthy backends, and 503 otherwise, so orchestrators do not send
+// traffic to a balancer with empty pools. Only pools outside required may be
+// empty, such as a default pool nothing was configured for.
+type ReadinessHandler struct {
+	loadBalancers map[string]*loadbalancer.LoadBalancer
+	required      map[string]bool
+	minHealthy    int
+}
+
+func NewReadinessHandler(loadBalancers map[string]*loadbalancer.LoadBalancer, required map[string]bool, minHealthy int) *ReadinessHandler {
+	return &ReadinessHandler{
+		loadBalancers: loadBalancers,
+		required:      required,
+		minHealthy:    minHealthy,
+	}
+}
+
+func (rh *ReadinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	view := readinessView{
+		Ready: true,
+		Pools: make(map[string]poolReadinessView, len(rh.loadBalancers)),
+	}
+
+	for name, lb := range rh.loadBalancers {
+		backends := lb.GetAllBackends()
+
+		// A pool waiting for discovery is empty, but not ready
+		if len(backends) == 0 && !rh.required[name] {
+			continue
+		}
+
+		pool := poolReadinessView{Total: len(backends)}
+
+		for _, b := range backends {
+			if b.IsAlive() {
+				pool.Healthy++
+			}
+		}
+
+		pool.Ready = pool.Healthy >= rh.minHealthy
+		view.Ready = view.Ready && pool.Ready
+		view.Pools[name] = pool
+	}
+
+	status := http.StatusOK
+
+	if !view.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, view)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+func generateRequestID() string {
+	buffer := make([]byte, 16)
+
+	if _, err := rand.Read(buffer); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(buffer)
+}
+
+// getRequestID returns the request ID sent by the client, or a new one when
+// the header is missing. The request is left unchanged, so backends only get
+// an ID when a header rule sends one.
+func getRequestID(r *http.Request) string {
+	if requestID := r.Header.Get(RequestIDHeader); requestID != "" {
+		return requestID
+	}
+
+	return generateRequestID()
+}
@@ -0,0 +1,106 @@
+package headers
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type Values struct {
+	ClientIP   string
+	BackendURL string
+	Host       string
+	Method     string
+	Path       string
+	Scheme     string
+
+	// RequestID is only called when a rule references {request_id}, as it
+	// may generate a new ID. The result is reused for the response rules.
+	RequestID func() string
+
+	requestID     string
+	requestIDOnce sync.Once
+}
+
+type Operations struct {
+	Set    map[string]string
+	Append map[string]string
+	Remove []string
+	Rename map[string]string
+}
+
+type Rules struct {
+	Request  Operations
+	Response Operations
+}
+
+func (v *Values) getRequestID() string {
+	v.requestIDOnce.Do(func() {
+		if v.RequestID != nil {
+			v.requestID = v.RequestID()
+		}
+	})
+
+	return v.requestID
+}
+
+func (v *Values) expand(template string) string {
+	if !strings.Contains(template, "{") {
+		return template
+	}
+
+	replacements := []string{
+		"{client_ip}", v.ClientIP,
+		"{backend_url}", v.BackendURL,
+		"{host}", v.Host,
+		"{method}", v.Method,
+		"{path}", v.Path,
+		"{scheme}", v.Scheme,
+	}
+
+	if strings.Contains(template, "{request_id}") {
+		replacements = append(replacements, "{request_id}", v.getRequestID())
+	}
+
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+func (o *Operations) IsEmpty() bool {
+	return len(o.Set) == 0 && len(o.Append) == 0 && len(o.Remove) == 0 && len(o.Rename) == 0
+}
+
+// Apply runs the operations in a fixed order: rename, remove, set, append.
+// Within each operation headers are handled in the order of their names, so
+// chained renames and overlapping rules give the same result every time.
+func (o *Operations) Apply(header http.Header, values *Values) {
+	if o.IsEmpty() {
+		return
+	}
+
+	for _, from := range slices.Sorted(maps.Keys(o.Rename)) {
+		to := o.Rename[from]
+
+		if headerValues := header.Values(from); len(headerValues) > 0 {
+			header.Del(from)
+			header.Del(to)
+
+			for _, value := range headerValues {
+				header.Add(to, value)
+			}
+		}
+	}
+
+	for _, name := range o.Remove {
+		header.Del(name)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(o.Set)) {
+		header.Set(name, values.expand(o.Set[name]))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(o.Append)) {
+		header.Add(name, values.expand(o.Append[name]))
+	}
+}
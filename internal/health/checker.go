@@ -1,62 +1,174 @@
 package health
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"maps"
-	"net/http"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/franciscodelahoz/load-balancer/internal/backend"
 )
 
+// target is a backend together with the health check settings that apply to
+// it after its overrides. Every target is checked on its own schedule.
+type target struct {
+	backend *backend.Backend
+	config  *Config
+	prober  Prober
+	stop    chan struct{}
+
+	history *History
+
+	// pending is set while a backend started out of rotation waits for its
+	// first successful check
+	pending bool
+
+	// transitions holds the times of recent state changes, and
+	// flappingUntil the end of the cooldown of a flapping backend
+	transitions   []time.Time
+	flappingUntil time.Time
+}
+
 type HealthChecker struct {
 	config      *Config
-	client      *http.Client
-	backends    []*backend.Backend
+	prober      Prober
+	targets     []*target
 	results     map[string]*Result
+	semaphore   chan struct{}
 	mutex       sync.RWMutex
 	stopChannel chan struct{}
 	wg          sync.WaitGroup
 	running     bool
+
+	subscribers      map[chan Event]struct{}
+	subscribersMutex sync.RWMutex
 }
 
 func NewHealthChecker(config *Config) *HealthChecker {
-	return &HealthChecker{
-		config: config,
-		client: &http.Client{
-			Timeout: config.Timeout,
-		},
-		backends:    make([]*backend.Backend, 0),
+	hc := &HealthChecker{
+		config:      config,
+		prober:      newProberOrFailing(config),
+		targets:     make([]*target, 0),
 		results:     make(map[string]*Result),
 		stopChannel: make(chan struct{}),
+		subscribers: make(map[chan Event]struct{}),
+	}
+
+	if config.MaxConcurrentChecks > 0 {
+		hc.semaphore = make(chan struct{}, config.MaxConcurrentChecks)
+	}
+
+	if len(config.Webhooks) > 0 {
+		events, _ := hc.Subscribe(defaultSubscriptionBuffer)
+		go NewWebhookNotifier(config.Webhooks).Run(events)
+	}
+
+	return hc
+}
+
+func newProberOrFailing(config *Config) Prober {
+	prober, err := NewProber(config)
+
+	if err != nil {
+		log.Printf("❌ Error creating health check prober: %v", err)
+		return &failingProber{err: err}
+	}
+
+	return prober
+}
+
+func (hc *HealthChecker) newTarget(backend *backend.Backend) *target {
+	if backend.HealthCheck == nil {
+		return &target{
+			backend: backend,
+			config:  hc.config,
+			prober:  hc.prober,
+			stop:    make(chan struct{}),
+			history: NewHistory(hc.config.HistorySize),
+		}
+	}
+
+	config := hc.config.WithOverride(backend.HealthCheck)
+
+	return &target{
+		backend: backend,
+		config:  config,
+		prober:  newProberOrFailing(config),
+		stop:    make(chan struct{}),
+		history: NewHistory(config.HistorySize),
+	}
+}
+
+// startTarget launches the monitoring loop of a target. The caller must hold
+// the mutex.
+func (hc *HealthChecker) startTarget(t *target) {
+	hc.wg.Add(1)
+	go hc.monitoringLoop(t)
+}
+
+// addTarget starts monitoring a new backend, taking it out of rotation until
+// its first check passes when the initial state is pessimistic. The caller
+// must hold the mutex.
+func (hc *HealthChecker) addTarget(backend *backend.Backend) *target {
+	t := hc.newTarget(backend)
+
+	if hc.config.InitialState == InitialStatePessimistic {
+		backend.SetHealth(false)
+		t.pending = true
+	}
+
+	hc.targets = append(hc.targets, t)
+
+	if hc.running {
+		hc.startTarget(t)
 	}
+
+	return t
 }
 
 func (hc *HealthChecker) RegisterBackend(backend *backend.Backend) {
 	hc.mutex.Lock()
 	defer hc.mutex.Unlock()
 
-	hc.backends = append(hc.backends, backend)
-	log.Printf("✅ Registered backend for health checking: %s", backend.URL.String())
+	t := hc.addTarget(backend)
+
+	log.Printf("✅ Registered backend for health checking: %s (interval: %s)", backend.URL.String(), t.config.Interval)
+}
+
+// UnregisterBackend stops monitoring a backend and forgets its results.
+func (hc *HealthChecker) UnregisterBackend(backend *backend.Backend) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	for i, t := range hc.targets {
+		if t.backend == backend {
+			close(t.stop)
+			hc.targets = append(hc.targets[:i], hc.targets[i+1:]...)
+			delete(hc.results, backend.URL.String())
+
+			log.Printf("🗑️ Unregistered backend from health checking: %s", backend.URL.String())
+			return
+		}
+	}
 }
 
 func (hc *HealthChecker) Start() {
 	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
 
 	if hc.running {
-		hc.mutex.Unlock()
 		return
 	}
 
 	hc.running = true
-	hc.mutex.Unlock()
 
-	hc.wg.Add(1)
-	go hc.monitoringLoop()
+	for _, t := range hc.targets {
+		hc.startTarget(t)
+	}
 
-	log.Printf("🏥 Health checker started with %d backends", len(hc.backends))
+	log.Printf("🏥 Health checker started with %d backends", len(hc.targets))
 }
 
 func (hc *HealthChecker) Stop() {
@@ -73,142 +185,245 @@ func (hc *HealthChecker) Stop() {
 	close(hc.stopChannel)
 
 	hc.wg.Wait()
+	hc.closeSubscriptions()
+
 	log.Println("🏥 Health checker stopped")
 }
 
-func (hc *HealthChecker) Check(backend *backend.Backend) *Result {
-	start := time.Now()
-
-	healthURL := backend.URL.String() + hc.config.Path
-
-	req, err := http.NewRequest(hc.config.Method, healthURL, nil)
+func (hc *HealthChecker) findTarget(backend *backend.Backend) *target {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
 
-	if err != nil {
-		return &Result{
-			Backend:   backend,
-			Status:    StatusUnhealthy,
-			Error:     err,
-			CheckedAt: time.Now(),
+	for _, t := range hc.targets {
+		if t.backend == backend {
+			return t
 		}
 	}
 
-	resp, err := hc.client.Do(req)
+	return nil
+}
+
+func (hc *HealthChecker) Check(backend *backend.Backend) *Result {
+	t := hc.findTarget(backend)
 
-	if err != nil {
-		return &Result{
-			Backend:   backend,
-			Status:    StatusUnhealthy,
-			Latency:   time.Since(start),
-			Error:     err,
-			CheckedAt: time.Now(),
-		}
+	if t == nil {
+		t = hc.newTarget(backend)
 	}
 
-	defer resp.Body.Close()
+	return hc.check(t)
+}
+
+func (hc *HealthChecker) check(t *target) *Result {
+	start := time.Now()
 
-	status := StatusUnhealthy
-	var statusError error = nil
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.Timeout)
+	defer cancel()
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		status = StatusHealthy
-	} else {
-		statusError = fmt.Errorf("unexpected HTTP status from backend: %d", resp.StatusCode)
+	err := t.prober.Probe(ctx, t.backend)
+
+	status := StatusHealthy
+
+	if err != nil {
+		status = StatusUnhealthy
 	}
 
 	return &Result{
-		Backend:   backend,
+		Backend:   t.backend,
 		Status:    status,
 		Latency:   time.Since(start),
+		Error:     err,
 		CheckedAt: time.Now(),
-		Error:     statusError,
 	}
 }
 
 func (hc *HealthChecker) StartMonitoring(backends []*backend.Backend) {
-	hc.mutex.Lock()
-	hc.backends = backends
-	hc.mutex.Unlock()
+	hc.UpdateBackends(backends)
+	hc.Start()
+}
 
-	hc.wg.Add(1)
-	go hc.monitoringLoop()
+func (hc *HealthChecker) StopMonitoring() {
+	hc.Stop()
+}
 
-	log.Printf("✅ Health checker started with %d backends", len(backends))
+// acquire waits for a free probe slot. It returns false when the target is
+// stopped while waiting.
+func (hc *HealthChecker) acquire(t *target) bool {
+	if hc.semaphore == nil {
+		return true
+	}
+
+	select {
+	case hc.semaphore <- struct{}{}:
+		return true
+	case <-t.stop:
+		return false
+	case <-hc.stopChannel:
+		return false
+	}
 }
 
-func (hc *HealthChecker) StopMonitoring() {
-	close(hc.stopChannel)
-	hc.wg.Wait()
-	log.Println("✅ Health checker stopped")
+func (hc *HealthChecker) release() {
+	if hc.semaphore != nil {
+		<-hc.semaphore
+	}
 }
 
-func (hc *HealthChecker) performHealthChecks() {
-	hc.mutex.RLock()
+func (hc *HealthChecker) checkTarget(t *target) {
+	if !hc.acquire(t) {
+		return
+	}
 
-	backends := make([]*backend.Backend, len(hc.backends))
-	copy(backends, hc.backends)
+	b := t.backend
+	result := hc.check(t)
 
-	hc.mutex.RUnlock()
+	hc.release()
 
-	if len(backends) == 0 {
+	hc.mutex.Lock()
+
+	select {
+	case <-t.stop:
+		// Removed while the probe was running
+		hc.mutex.Unlock()
 		return
+	default:
 	}
 
-	var wg sync.WaitGroup
+	hc.results[b.URL.String()] = result
+	hc.mutex.Unlock()
 
-	for _, backend := range backends {
-		wg.Add(1)
-		b := backend
+	t.history.Add(result)
+
+	if result.Status == StatusHealthy {
+		b.IncreaseConsecutiveSuccesses()
+		b.ResetConsecutiveErrors()
+
+		log.Printf("✅ Backend %s health check passed (latency: %s)", b.URL.String(), result.Latency)
+
+		if hc.isFlapping(t, result.CheckedAt) {
+			return
+		}
+
+		if t.pending {
+			t.pending = false
+
+			log.Printf("✅ Backend %s marked as healthy after its first successful check", b.URL.String())
+			hc.transition(t, true, result)
+		} else if b.GetConsecutiveSuccesses() >= t.config.SuccessThreshold && !b.IsAlive() {
+			log.Printf("✅ Backend %s marked as healthy after %d consecutive successes", b.URL.String(), b.GetConsecutiveSuccesses())
+			hc.transition(t, true, result)
+		}
 
-		go func() {
-			defer wg.Done()
+	} else {
+		b.ResetConsecutiveSuccesses()
+		b.IncreaseConsecutiveErrors()
+
+		log.Printf("❌ Backend %s health check failed: %v", b.URL.String(), result.Error)
+		hc.publish(Event{Type: EventCheckFailed, Backend: b, Result: result, Time: time.Now()})
+
+		if b.GetConsecutiveErrors() >= t.config.FailureThreshold && b.IsAlive() {
+			log.Printf("❌ Backend %s marked as unhealthy after %d consecutive errors", b.URL.String(), b.GetConsecutiveErrors())
+			hc.transition(t, false, result)
+		}
+	}
+}
+
+// isFlapping reports whether a backend is still in its flapping cooldown.
+func (hc *HealthChecker) isFlapping(t *target, now time.Time) bool {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	return now.Before(t.flappingUntil)
+}
 
-			result := hc.Check(b)
+// transition changes the state of a backend. When flap detection is enabled
+// and the backend changed state too often, it is held out of rotation for
+// the cooldown instead.
+func (hc *HealthChecker) transition(t *target, healthy bool, result *Result) {
+	b := t.backend
+	flapDetection := t.config.FlapDetection
 
-			hc.mutex.Lock()
-			hc.results[b.URL.String()] = result
-			hc.mutex.Unlock()
+	if flapDetection != nil && flapDetection.MaxTransitions > 0 {
+		hc.mutex.Lock()
 
-			if result.Status == StatusHealthy {
-				b.IncreaseConsecutiveSuccesses()
-				b.ResetConsecutiveErrors()
+		t.transitions = append(t.transitions, result.CheckedAt)
 
-				log.Printf("✅ Backend %s health check passed (latency: %s)", b.URL.String(), result.Latency)
+		for len(t.transitions) > 0 && result.CheckedAt.Sub(t.transitions[0]) > flapDetection.Window {
+			t.transitions = t.transitions[1:]
+		}
 
-				if b.GetConsecutiveSuccesses() >= hc.config.SuccessThreshold && !b.IsAlive() {
-					b.SetHealth(true)
-					log.Printf("✅ Backend %s marked as healthy after %d consecutive successes", b.URL.String(), b.GetConsecutiveSuccesses())
-				}
+		flapping := len(t.transitions) > flapDetection.MaxTransitions
 
-			} else {
-				b.ResetConsecutiveSuccesses()
-				b.IncreaseConsecutiveErrors()
+		if flapping {
+			t.transitions = nil
+			t.flappingUntil = result.CheckedAt.Add(flapDetection.Cooldown)
+		}
+
+		hc.mutex.Unlock()
 
-				log.Printf("❌ Backend %s health check failed: %v", b.URL.String(), result.Error)
+		if flapping {
+			b.SetHealth(false)
+			log.Printf("⚠️ Backend %s is flapping, held out of rotation for %s", b.URL.String(), flapDetection.Cooldown)
 
-				if b.GetConsecutiveErrors() >= hc.config.FailureThreshold && b.IsAlive() {
-					b.SetHealth(false)
-					log.Printf("❌ Backend %s marked as unhealthy after %d consecutive errors", b.URL.String(), b.GetConsecutiveErrors())
-				}
-			}
-		}()
+			hc.publish(Event{Type: EventBackendFlapping, Backend: b, Result: result, Time: time.Now()})
+			return
+		}
 	}
 
-	wg.Wait()
+	b.SetHealth(healthy)
+
+	eventType := EventBackendUnhealthy
+
+	if healthy {
+		eventType = EventBackendHealthy
+	}
+
+	hc.publish(Event{Type: eventType, Backend: b, Result: result, Time: time.Now()})
 }
 
-func (hc *HealthChecker) monitoringLoop() {
-	defer hc.wg.Done()
+// nextInterval returns the delay until the next check of a target. Backends
+// that are out of rotation are probed exponentially less often, up to
+// MaxBackoffInterval, and every delay is randomized by Jitter so that checks
+// of many backends do not line up.
+func (t *target) nextInterval() time.Duration {
+	interval := t.config.Interval
+
+	if maxInterval := t.config.MaxBackoffInterval; maxInterval > interval && !t.backend.IsAlive() {
+		failures := t.backend.GetConsecutiveErrors() - t.config.FailureThreshold
+
+		for i := 0; i < failures && interval < maxInterval; i++ {
+			interval *= 2
+		}
+
+		interval = min(interval, maxInterval)
+	}
+
+	return jitter(interval, t.config.Jitter)
+}
+
+// jitter spreads a duration uniformly over +/- fraction of its value.
+func jitter(interval time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return interval
+	}
 
-	ticker := time.NewTicker(hc.config.Interval)
-	defer ticker.Stop()
+	return interval + time.Duration((rand.Float64()*2-1)*fraction*float64(interval))
+}
 
-	// Initial check
-	hc.performHealthChecks()
+func (hc *HealthChecker) monitoringLoop(t *target) {
+	defer hc.wg.Done()
+
+	// Start each backend at a random offset within the jitter window, so
+	// the initial checks are spread out as well
+	timer := time.NewTimer(time.Duration(rand.Float64() * t.config.Jitter * float64(t.config.Interval)))
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			hc.performHealthChecks()
+		case <-timer.C:
+			hc.checkTarget(t)
+			timer.Reset(t.nextInterval())
+		case <-t.stop:
+			return
 		case <-hc.stopChannel:
 			return
 		}
@@ -224,10 +439,60 @@ func (hc *HealthChecker) GetResults() map[string]*Result {
 	return results
 }
 
+// BackendHistory is the recent check history of a backend.
+type BackendHistory struct {
+	Results       []*Result
+	Summary       HistorySummary
+	FlappingUntil time.Time
+}
+
+// GetHistory returns the check history of every monitored backend, keyed by
+// backend URL.
+func (hc *HealthChecker) GetHistory() map[string]*BackendHistory {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	histories := make(map[string]*BackendHistory, len(hc.targets))
+
+	for _, t := range hc.targets {
+		histories[t.backend.URL.String()] = &BackendHistory{
+			Results:       t.history.Results(),
+			Summary:       t.history.Summary(),
+			FlappingUntil: t.flappingUntil,
+		}
+	}
+
+	return histories
+}
+
+// UpdateBackends replaces the monitored backends. Backends that are kept
+// continue on their current schedule.
 func (hc *HealthChecker) UpdateBackends(backends []*backend.Backend) {
 	hc.mutex.Lock()
-	hc.backends = backends
-	hc.mutex.Unlock()
+	defer hc.mutex.Unlock()
+
+	existing := make(map[*backend.Backend]*target, len(hc.targets))
+
+	for _, t := range hc.targets {
+		existing[t.backend] = t
+	}
+
+	hc.targets = make([]*target, 0, len(backends))
+
+	for _, backend := range backends {
+		if t, exists := existing[backend]; exists {
+			hc.targets = append(hc.targets, t)
+			delete(existing, backend)
+			continue
+		}
+
+		hc.addTarget(backend)
+	}
+
+	for backend, t := range existing {
+		close(t.stop)
+		delete(hc.results, backend.URL.String())
+	}
 
 	log.Printf("📝 Health checker updated with %d backends", len(backends))
 }
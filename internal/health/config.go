@@ -1,12 +1,120 @@
 package health
 
-import "time"
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+)
+
+const (
+	CheckTypeHTTP = "http"
+	CheckTypeTCP  = "tcp"
+	CheckTypeGRPC = "grpc"
+	CheckTypeExec = "exec"
+)
+
+// Initial states decide whether a backend receives traffic before its first
+// health check has completed.
+const (
+	InitialStateOptimistic  = "optimistic"
+	InitialStatePessimistic = "pessimistic"
+)
+
+// FlapDetectionConfig holds a backend out of rotation for Cooldown once it
+// changes state more than MaxTransitions times within Window.
+type FlapDetectionConfig struct {
+	MaxTransitions int
+	Window         time.Duration
+	Cooldown       time.Duration
+}
 
 type Config struct {
+	Type             string
 	Interval         time.Duration
 	Timeout          time.Duration
 	Path             string
+	Port             int
 	Method           string
 	SuccessThreshold int
 	FailureThreshold int
+	GRPCService      string
+	Command          []string
+
+	// Scheduling
+	InitialState        string
+	Jitter              float64
+	MaxBackoffInterval  time.Duration
+	MaxConcurrentChecks int
+
+	// History and flap detection
+	HistorySize   int
+	FlapDetection *FlapDetectionConfig
+
+	// Webhooks notified of backend state transitions
+	Webhooks []WebhookConfig
+
+	// HTTP response assertions
+	ExpectedStatuses []string
+	BodyContains     string
+	BodyRegex        string
+	JSONPath         string
+	JSONValue        string
+	Headers          map[string]string
+	Host             string
+	MaxResponseTime  time.Duration
+}
+
+// WithOverride returns a copy of the config with the backend's overrides
+// applied on top of it.
+func (c *Config) WithOverride(override *backend.HealthCheckOverride) *Config {
+	merged := *c
+
+	if override == nil {
+		return &merged
+	}
+
+	if override.Path != "" {
+		merged.Path = override.Path
+	}
+
+	if override.Port != 0 {
+		merged.Port = override.Port
+	}
+
+	if override.Method != "" {
+		merged.Method = override.Method
+	}
+
+	if override.Interval != 0 {
+		merged.Interval = override.Interval
+	}
+
+	if override.Timeout != 0 {
+		merged.Timeout = override.Timeout
+	}
+
+	if override.SuccessThreshold != 0 {
+		merged.SuccessThreshold = override.SuccessThreshold
+	}
+
+	if override.FailureThreshold != 0 {
+		merged.FailureThreshold = override.FailureThreshold
+	}
+
+	return &merged
+}
+
+// targetURL returns the backend URL to probe, moved to the configured health
+// check port when one is set.
+func (c *Config) targetURL(backend *backend.Backend) *url.URL {
+	target := *backend.URL
+
+	if c.Port != 0 {
+		target.Host = net.JoinHostPort(backend.URL.Hostname(), strconv.Itoa(c.Port))
+	}
+
+	return &target
 }
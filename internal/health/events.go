@@ -0,0 +1,87 @@
+package health
+
+import (
+	"log"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+)
+
+type EventType string
+
+const (
+	EventBackendHealthy   EventType = "backend_healthy"
+	EventBackendUnhealthy EventType = "backend_unhealthy"
+	EventBackendFlapping  EventType = "backend_flapping"
+	EventCheckFailed      EventType = "check_failed"
+)
+
+const defaultSubscriptionBuffer = 64
+
+// Event describes a health check outcome worth reacting to. Transition events
+// are published once per state change, EventCheckFailed after every failed
+// check.
+type Event struct {
+	Type    EventType
+	Backend *backend.Backend
+	Result  *Result
+	Time    time.Time
+}
+
+// IsTransition reports whether the event is a change of the backend state.
+// A flapping backend is held out of rotation, so that counts as one too.
+func (e Event) IsTransition() bool {
+	return e.Type == EventBackendHealthy || e.Type == EventBackendUnhealthy || e.Type == EventBackendFlapping
+}
+
+// Subscribe returns a channel that receives every event published by the
+// checker, and a function that cancels the subscription. Events are dropped
+// for subscribers that fall more than buffer events behind, so a slow
+// consumer never delays health checking. The channel is closed when the
+// subscription is cancelled or the checker stops.
+func (hc *HealthChecker) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer <= 0 {
+		buffer = defaultSubscriptionBuffer
+	}
+
+	events := make(chan Event, buffer)
+
+	hc.subscribersMutex.Lock()
+	hc.subscribers[events] = struct{}{}
+	hc.subscribersMutex.Unlock()
+
+	unsubscribe := func() {
+		hc.subscribersMutex.Lock()
+		defer hc.subscribersMutex.Unlock()
+
+		if _, exists := hc.subscribers[events]; exists {
+			delete(hc.subscribers, events)
+			close(events)
+		}
+	}
+
+	return events, unsubscribe
+}
+
+func (hc *HealthChecker) publish(event Event) {
+	hc.subscribersMutex.RLock()
+	defer hc.subscribersMutex.RUnlock()
+
+	for subscriber := range hc.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			log.Printf("⚠️ Dropped health event %s for %s: subscriber is not keeping up", event.Type, event.Backend.URL.String())
+		}
+	}
+}
+
+func (hc *HealthChecker) closeSubscriptions() {
+	hc.subscribersMutex.Lock()
+	defer hc.subscribersMutex.Unlock()
+
+	for subscriber := range hc.subscribers {
+		close(subscriber)
+		delete(hc.subscribers, subscriber)
+	}
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+)
+
+const (
+	execMaxOutput = 256
+
+	// execWaitDelay bounds the wait for the output of a command killed on
+	// timeout, as children it started may keep the pipes open
+	execWaitDelay = time.Second
+)
+
+// cappedBuffer keeps the first bytes written to it and discards the rest, so a
+// chatty command cannot grow the memory of the balancer.
+type cappedBuffer struct {
+	buffer bytes.Buffer
+	limit  int
+}
+
+func (cb *cappedBuffer) Write(data []byte) (int, error) {
+	if remaining := cb.limit - cb.buffer.Len(); remaining > 0 {
+		cb.buffer.Write(data[:min(len(data), remaining)])
+	}
+
+	return len(data), nil
+}
+
+// ExecProber runs a local command and treats exit code 0 as healthy. The
+// "{address}", "{host}", "{port}" and "{url}" placeholders in the arguments
+// are replaced with the backend's values, which are also exported as the
+// BACKEND_ADDRESS, BACKEND_HOST, BACKEND_PORT and BACKEND_URL variables.
+type ExecProber struct {
+	config  *Config
+	command []string
+}
+
+func NewExecProber(config *Config) (Prober, error) {
+	if len(config.Command) == 0 {
+		return nil, errors.New("exec health check requires a command")
+	}
+
+	return &ExecProber{
+		config:  config,
+		command: config.Command,
+	}, nil
+}
+
+func (ep *ExecProber) Probe(ctx context.Context, backend *backend.Backend) error {
+	target := ep.config.targetURL(backend)
+
+	replacer := strings.NewReplacer(
+		"{address}", target.Host,
+		"{host}", target.Hostname(),
+		"{port}", target.Port(),
+		"{url}", target.String(),
+	)
+
+	args := make([]string, len(ep.command))
+
+	for i, arg := range ep.command {
+		args[i] = replacer.Replace(arg)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"BACKEND_ADDRESS="+target.Host,
+		"BACKEND_HOST="+target.Hostname(),
+		"BACKEND_PORT="+target.Port(),
+		"BACKEND_URL="+target.String(),
+	)
+
+	cmd.WaitDelay = execWaitDelay
+
+	output := &cappedBuffer{limit: execMaxOutput}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+
+	if err == nil {
+		return nil
+	}
+
+	message := strings.TrimSpace(output.buffer.String())
+
+	if message == "" {
+		return fmt.Errorf("health check command failed: %w", err)
+	}
+
+	return fmt.Errorf("health check command failed: %w: %s", err, message)
+}
@@ -0,0 +1,204 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+)
+
+const (
+	grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+	// grpc.health.v1.HealthCheckResponse.ServingStatus values
+	grpcServingStatusUnknown        = 0
+	grpcServingStatusServing        = 1
+	grpcServingStatusNotServing     = 2
+	grpcServingStatusServiceUnknown = 3
+
+	grpcMaxResponseSize = 4096
+)
+
+// GRPCProber implements the standard grpc.health.v1 Health/Check call over
+// HTTP/2. Backends with an https or grpcs URL are checked over TLS, any other
+// scheme uses HTTP/2 with prior knowledge (h2c).
+type GRPCProber struct {
+	config *Config
+	client *http.Client
+}
+
+func NewGRPCProber(config *Config) (Prober, error) {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Protocols = protocols
+
+	return &GRPCProber{
+		config: config,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+		},
+	}, nil
+}
+
+func grpcServingStatusName(status uint64) string {
+	switch status {
+	case grpcServingStatusUnknown:
+		return "UNKNOWN"
+	case grpcServingStatusServing:
+		return "SERVING"
+	case grpcServingStatusNotServing:
+		return "NOT_SERVING"
+	case grpcServingStatusServiceUnknown:
+		return "SERVICE_UNKNOWN"
+	default:
+		return fmt.Sprintf("%d", status)
+	}
+}
+
+// encodeHealthCheckRequest builds a length-prefixed gRPC message holding
+// HealthCheckRequest{service: service}.
+func encodeHealthCheckRequest(service string) []byte {
+	var message []byte
+
+	if service != "" {
+		message = append(message, 0x0A)
+		message = binary.AppendUvarint(message, uint64(len(service)))
+		message = append(message, service...)
+	}
+
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(message)))
+
+	return append(frame, message...)
+}
+
+// decodeServingStatus reads the status field of a HealthCheckResponse.
+func decodeServingStatus(frame []byte) (uint64, error) {
+	if len(frame) < 5 {
+		return 0, errors.New("truncated gRPC response")
+	}
+
+	if frame[0] != 0 {
+		return 0, errors.New("compressed gRPC responses are not supported")
+	}
+
+	length := binary.BigEndian.Uint32(frame[1:5])
+
+	if uint32(len(frame)-5) < length {
+		return 0, errors.New("truncated gRPC response message")
+	}
+
+	message := frame[5 : 5+length]
+	status := uint64(grpcServingStatusUnknown)
+
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+
+		if n <= 0 {
+			return 0, errors.New("malformed gRPC response")
+		}
+
+		message = message[n:]
+
+		switch tag & 0x7 {
+		case 0:
+			value, n := binary.Uvarint(message)
+
+			if n <= 0 {
+				return 0, errors.New("malformed gRPC response")
+			}
+
+			if tag>>3 == 1 {
+				status = value
+			}
+
+			message = message[n:]
+		case 1:
+			message = message[min(8, len(message)):]
+		case 2:
+			length, n := binary.Uvarint(message)
+
+			if n <= 0 || uint64(len(message)-n) < length {
+				return 0, errors.New("malformed gRPC response")
+			}
+
+			message = message[n+int(length):]
+		case 5:
+			message = message[min(4, len(message)):]
+		default:
+			return 0, errors.New("malformed gRPC response")
+		}
+	}
+
+	return status, nil
+}
+
+func (gp *GRPCProber) Probe(ctx context.Context, backend *backend.Backend) error {
+	scheme := "http"
+
+	if backend.URL.Scheme == "https" || backend.URL.Scheme == "grpcs" {
+		scheme = "https"
+	}
+
+	checkURL := fmt.Sprintf("%s://%s%s", scheme, gp.config.targetURL(backend).Host, grpcHealthCheckPath)
+	body := encodeHealthCheckRequest(gp.config.GRPCService)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, checkURL, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := gp.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected HTTP status from gRPC backend: %d", resp.StatusCode)
+	}
+
+	frame, err := io.ReadAll(io.LimitReader(resp.Body, grpcMaxResponseSize))
+
+	if err != nil {
+		return err
+	}
+
+	// Trailers-only responses carry the status in the headers
+	grpcStatus := resp.Trailer.Get("Grpc-Status")
+
+	if grpcStatus == "" {
+		grpcStatus = resp.Header.Get("Grpc-Status")
+	}
+
+	if grpcStatus != "0" {
+		return fmt.Errorf("gRPC health check failed with status %s: %s", grpcStatus, resp.Trailer.Get("Grpc-Message"))
+	}
+
+	servingStatus, err := decodeServingStatus(frame)
+
+	if err != nil {
+		return err
+	}
+
+	if servingStatus != grpcServingStatusServing {
+		return fmt.Errorf("gRPC backend is %s", grpcServingStatusName(servingStatus))
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const DefaultHistorySize = 20
+
+// History keeps the most recent results of a backend in a ring buffer.
+type History struct {
+	results []*Result
+	next    int
+	count   int
+	mutex   sync.RWMutex
+}
+
+type HistorySummary struct {
+	Checks       int
+	Successes    int
+	SuccessRatio float64
+	LatencyP50   time.Duration
+	LatencyP90   time.Duration
+	LatencyP99   time.Duration
+}
+
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+
+	return &History{
+		results: make([]*Result, size),
+	}
+}
+
+func (h *History) Add(result *Result) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.results[h.next] = result
+	h.next = (h.next + 1) % len(h.results)
+	h.count = min(h.count+1, len(h.results))
+}
+
+// Results returns the stored results, oldest first.
+func (h *History) Results() []*Result {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	results := make([]*Result, 0, h.count)
+	start := (h.next - h.count + len(h.results)) % len(h.results)
+
+	for i := 0; i < h.count; i++ {
+		results = append(results, h.results[(start+i)%len(h.results)])
+	}
+
+	return results
+}
+
+func (h *History) Summary() HistorySummary {
+	results := h.Results()
+	summary := HistorySummary{Checks: len(results)}
+
+	if len(results) == 0 {
+		return summary
+	}
+
+	latencies := make([]time.Duration, 0, len(results))
+
+	for _, result := range results {
+		if result.Status == StatusHealthy {
+			summary.Successes++
+		}
+
+		latencies = append(latencies, result.Latency)
+	}
+
+	slices.Sort(latencies)
+
+	summary.SuccessRatio = float64(summary.Successes) / float64(len(results))
+	summary.LatencyP50 = percentile(latencies, 0.50)
+	summary.LatencyP90 = percentile(latencies, 0.90)
+	summary.LatencyP99 = percentile(latencies, 0.99)
+
+	return summary
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
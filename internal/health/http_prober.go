@@ -0,0 +1,257 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/jsonpath"
+)
+
+const httpMaxBodySize = 64 * 1024
+
+type statusRange struct {
+	low  int
+	high int
+}
+
+// HTTPProber requests the configured path and checks the response against
+// the configured assertions. Without any, every 2xx status counts as healthy.
+type HTTPProber struct {
+	config    *Config
+	client    *http.Client
+	statuses  []statusRange
+	bodyRegex *regexp.Regexp
+	jsonPath  []string
+}
+
+func NewHTTPProber(config *Config) (Prober, error) {
+	prober := &HTTPProber{
+		config: config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+		},
+		statuses: []statusRange{{low: 200, high: 299}},
+	}
+
+	if len(config.ExpectedStatuses) > 0 {
+		prober.statuses = make([]statusRange, 0, len(config.ExpectedStatuses))
+
+		for _, spec := range config.ExpectedStatuses {
+			statuses, err := parseStatusRange(spec)
+
+			if err != nil {
+				return nil, err
+			}
+
+			prober.statuses = append(prober.statuses, statuses)
+		}
+	}
+
+	if config.BodyRegex != "" {
+		bodyRegex, err := regexp.Compile(config.BodyRegex)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid health check body_regex: %w", err)
+		}
+
+		prober.bodyRegex = bodyRegex
+	}
+
+	if config.JSONPath != "" {
+		jsonPath, err := parseJSONPath(config.JSONPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		prober.jsonPath = jsonPath
+	}
+
+	return prober, nil
+}
+
+// parseStatusRange accepts a single code ("204"), an inclusive range
+// ("200-299") or a class ("2xx").
+func parseStatusRange(spec string) (statusRange, error) {
+	spec = strings.TrimSpace(spec)
+
+	if len(spec) == 3 && strings.HasSuffix(strings.ToLower(spec), "xx") {
+		class, err := strconv.Atoi(spec[:1])
+
+		if err != nil || class < 1 || class > 5 {
+			return statusRange{}, fmt.Errorf("invalid health check status: %s", spec)
+		}
+
+		return statusRange{low: class * 100, high: class*100 + 99}, nil
+	}
+
+	lowText, highText, isRange := strings.Cut(spec, "-")
+
+	low, err := strconv.Atoi(strings.TrimSpace(lowText))
+
+	if err != nil {
+		return statusRange{}, fmt.Errorf("invalid health check status: %s", spec)
+	}
+
+	high := low
+
+	if isRange {
+		high, err = strconv.Atoi(strings.TrimSpace(highText))
+
+		if err != nil {
+			return statusRange{}, fmt.Errorf("invalid health check status: %s", spec)
+		}
+	}
+
+	if low < 100 || high > 599 || low > high {
+		return statusRange{}, fmt.Errorf("invalid health check status: %s", spec)
+	}
+
+	return statusRange{low: low, high: high}, nil
+}
+
+func (hp *HTTPProber) isExpectedStatus(code int) bool {
+	for _, statuses := range hp.statuses {
+		if code >= statuses.low && code <= statuses.high {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (hp *HTTPProber) needsBody() bool {
+	return hp.config.BodyContains != "" || hp.bodyRegex != nil || hp.jsonPath != nil
+}
+
+func (hp *HTTPProber) Probe(ctx context.Context, backend *backend.Backend) error {
+	healthURL := hp.config.targetURL(backend).String() + hp.config.Path
+
+	req, err := http.NewRequestWithContext(ctx, hp.config.Method, healthURL, nil)
+
+	if err != nil {
+		return err
+	}
+
+	for name, value := range hp.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if hp.config.Host != "" {
+		req.Host = hp.config.Host
+	}
+
+	start := time.Now()
+	resp, err := hp.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if !hp.isExpectedStatus(resp.StatusCode) {
+		return fmt.Errorf("unexpected HTTP status from backend: %d", resp.StatusCode)
+	}
+
+	var body []byte
+
+	if hp.needsBody() {
+		body, err = io.ReadAll(io.LimitReader(resp.Body, httpMaxBodySize))
+
+		if err != nil {
+			return fmt.Errorf("error reading health check response: %w", err)
+		}
+	}
+
+	if elapsed := time.Since(start); hp.config.MaxResponseTime > 0 && elapsed > hp.config.MaxResponseTime {
+		return fmt.Errorf("health check took %s, exceeding max response time of %s", elapsed, hp.config.MaxResponseTime)
+	}
+
+	if hp.config.BodyContains != "" && !bytes.Contains(body, []byte(hp.config.BodyContains)) {
+		return fmt.Errorf("response body does not contain %q", hp.config.BodyContains)
+	}
+
+	if hp.bodyRegex != nil && !hp.bodyRegex.Match(body) {
+		return fmt.Errorf("response body does not match %q", hp.config.BodyRegex)
+	}
+
+	if hp.jsonPath != nil {
+		return hp.checkJSON(body)
+	}
+
+	return nil
+}
+
+func (hp *HTTPProber) checkJSON(body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var document any
+
+	if err := decoder.Decode(&document); err != nil {
+		return fmt.Errorf("response body is not valid JSON: %w", err)
+	}
+
+	value, found := jsonpath.Lookup(document, hp.jsonPath)
+
+	if !found {
+		return fmt.Errorf("JSON path %s not found in response", hp.config.JSONPath)
+	}
+
+	if hp.config.JSONValue == "" {
+		return nil
+	}
+
+	if actual := formatJSONValue(value); actual != hp.config.JSONValue {
+		return fmt.Errorf("JSON path %s is %q, expected %q", hp.config.JSONPath, actual, hp.config.JSONValue)
+	}
+
+	return nil
+}
+
+// parseJSONPath splits a simple JSONPath expression such as
+// "$.checks[0].status" or "checks.0.status" into its keys.
+func parseJSONPath(path string) ([]string, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	path = strings.TrimPrefix(path, ".")
+
+	if path == "" {
+		return nil, errors.New("health check json_path must select a field")
+	}
+
+	keys := strings.Split(path, ".")
+
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("invalid health check json_path: %s", path)
+		}
+	}
+
+	return keys, nil
+}
+
+func formatJSONValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case json.Number, bool:
+		return fmt.Sprint(v)
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
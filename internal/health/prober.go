@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+)
+
+// Prober runs a single health probe against a backend. A nil error means the
+// backend is healthy. The context carries the check timeout.
+type Prober interface {
+	Probe(ctx context.Context, backend *backend.Backend) error
+}
+
+type ProberFactory func(config *Config) (Prober, error)
+
+var (
+	proberFactories = map[string]ProberFactory{
+		CheckTypeHTTP: NewHTTPProber,
+		CheckTypeTCP:  NewTCPProber,
+		CheckTypeGRPC: NewGRPCProber,
+		CheckTypeExec: NewExecProber,
+	}
+	proberFactoriesMutex sync.RWMutex
+)
+
+// RegisterProber makes a new check type available to every health checker.
+func RegisterProber(checkType string, factory ProberFactory) {
+	proberFactoriesMutex.Lock()
+	defer proberFactoriesMutex.Unlock()
+
+	proberFactories[checkType] = factory
+}
+
+func NewProber(config *Config) (Prober, error) {
+	checkType := config.Type
+
+	if checkType == "" {
+		checkType = CheckTypeHTTP
+	}
+
+	proberFactoriesMutex.RLock()
+	factory, exists := proberFactories[checkType]
+	proberFactoriesMutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown health check type: %s", checkType)
+	}
+
+	return factory(config)
+}
+
+// failingProber reports the error that prevented the real prober from being
+// created, so a misconfigured check marks backends unhealthy instead of
+// silently passing.
+type failingProber struct {
+	err error
+}
+
+func (fp *failingProber) Probe(ctx context.Context, backend *backend.Backend) error {
+	return fp.err
+}
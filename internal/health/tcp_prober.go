@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"net"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+)
+
+type TCPProber struct {
+	config *Config
+	dialer *net.Dialer
+}
+
+func NewTCPProber(config *Config) (Prober, error) {
+	return &TCPProber{
+		config: config,
+		dialer: &net.Dialer{Timeout: config.Timeout},
+	}, nil
+}
+
+func (tp *TCPProber) Probe(ctx context.Context, backend *backend.Backend) error {
+	conn, err := tp.dialer.DialContext(ctx, "tcp", tp.config.targetURL(backend).Host)
+
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const defaultWebhookTimeout = 5 * time.Second
+
+type WebhookConfig struct {
+	URL     string
+	Headers map[string]string
+}
+
+// webhookPayload is the JSON body posted to webhooks on state transitions.
+type webhookPayload struct {
+	Event     EventType `json:"event"`
+	Backend   string    `json:"backend"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// WebhookNotifier posts backend state transitions to a set of URLs.
+type WebhookNotifier struct {
+	webhooks []WebhookConfig
+	client   *http.Client
+}
+
+func NewWebhookNotifier(webhooks []WebhookConfig) *WebhookNotifier {
+	return &WebhookNotifier{
+		webhooks: webhooks,
+		client: &http.Client{
+			Timeout: defaultWebhookTimeout,
+		},
+	}
+}
+
+// Run delivers transition events until the channel is closed.
+func (wn *WebhookNotifier) Run(events <-chan Event) {
+	for event := range events {
+		if !event.IsTransition() {
+			continue
+		}
+
+		for _, webhook := range wn.webhooks {
+			if err := wn.send(webhook, event); err != nil {
+				log.Printf("❌ Error sending health webhook to %s: %v", webhook.URL, err)
+			}
+		}
+	}
+}
+
+func (wn *WebhookNotifier) send(webhook WebhookConfig, event Event) error {
+	payload := webhookPayload{
+		Event:     event.Type,
+		Backend:   event.Backend.URL.String(),
+		Status:    event.Result.Status.String(),
+		LatencyMs: event.Result.Latency.Milliseconds(),
+		CheckedAt: event.Result.CheckedAt,
+	}
+
+	if event.Result.Error != nil {
+		payload.Error = event.Result.Error.Error()
+	}
+
+	body, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for name, value := range webhook.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := wn.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected HTTP status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
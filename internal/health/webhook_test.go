@@ -0,0 +1,149 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+)
+
+type receivedWebhook struct {
+	header  http.Header
+	payload webhookPayload
+}
+
+// startWebhookReceiver records the webhooks it receives and answers with
+// status.
+func startWebhookReceiver(t *testing.T, status int) (*httptest.Server, <-chan receivedWebhook) {
+	t.Helper()
+
+	received := make(chan receivedWebhook, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid webhook body: %v", err)
+		}
+
+		received <- receivedWebhook{header: r.Header, payload: payload}
+		w.WriteHeader(status)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, received
+}
+
+func testEvent(eventType EventType, status Status, checkErr error) Event {
+	b := backend.CreateBackendInstance(url.URL{Scheme: "http", Host: "10.0.0.1:8080"}, 1, 0)
+
+	return Event{
+		Type:    eventType,
+		Backend: b,
+		Result: &Result{
+			Backend:   b,
+			Status:    status,
+			Latency:   42 * time.Millisecond,
+			Error:     checkErr,
+			CheckedAt: time.Date(2025, 9, 10, 10, 50, 24, 0, time.UTC),
+		},
+		Time: time.Now(),
+	}
+}
+
+func TestWebhookNotifierPostsTransitions(t *testing.T) {
+	server, received := startWebhookReceiver(t, http.StatusNoContent)
+
+	notifier := NewWebhookNotifier([]WebhookConfig{{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	}})
+
+	events := make(chan Event, 3)
+	events <- testEvent(EventCheckFailed, StatusUnhealthy, errors.New("connection refused"))
+	events <- testEvent(EventBackendUnhealthy, StatusUnhealthy, errors.New("connection refused"))
+	events <- testEvent(EventBackendHealthy, StatusHealthy, nil)
+	close(events)
+
+	notifier.Run(events)
+
+	if len(received) != 2 {
+		t.Fatalf("received %d webhooks, want 2 (failed checks are not transitions)", len(received))
+	}
+
+	unhealthy := <-received
+
+	if got := unhealthy.header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+	}
+
+	if got := unhealthy.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	want := webhookPayload{
+		Event:     EventBackendUnhealthy,
+		Backend:   "http://10.0.0.1:8080",
+		Status:    StatusUnhealthy.String(),
+		Error:     "connection refused",
+		LatencyMs: 42,
+		CheckedAt: time.Date(2025, 9, 10, 10, 50, 24, 0, time.UTC),
+	}
+
+	if unhealthy.payload != want {
+		t.Errorf("payload = %+v, want %+v", unhealthy.payload, want)
+	}
+
+	healthy := <-received
+
+	if healthy.payload.Event != EventBackendHealthy || healthy.payload.Error != "" {
+		t.Errorf("payload = %+v, want a backend_healthy event without error", healthy.payload)
+	}
+}
+
+func TestWebhookNotifierReportsFailedDelivery(t *testing.T) {
+	server, received := startWebhookReceiver(t, http.StatusInternalServerError)
+
+	notifier := NewWebhookNotifier([]WebhookConfig{{URL: server.URL}})
+
+	err := notifier.send(notifier.webhooks[0], testEvent(EventBackendUnhealthy, StatusUnhealthy, nil))
+
+	if err == nil {
+		t.Errorf("send succeeded on a 500 response, want an error")
+	}
+
+	if len(received) != 1 {
+		t.Errorf("received %d webhooks, want 1", len(received))
+	}
+}
+
+func TestWebhookNotifierContinuesAfterFailure(t *testing.T) {
+	failing, _ := startWebhookReceiver(t, http.StatusBadGateway)
+	working, received := startWebhookReceiver(t, http.StatusOK)
+
+	notifier := NewWebhookNotifier([]WebhookConfig{{URL: failing.URL}, {URL: working.URL}})
+
+	events := make(chan Event, 1)
+	events <- testEvent(EventBackendFlapping, StatusUnhealthy, nil)
+	close(events)
+
+	notifier.Run(events)
+
+	if len(received) != 1 {
+		t.Fatalf("received %d webhooks on the working receiver, want 1", len(received))
+	}
+
+	if event := (<-received).payload.Event; event != EventBackendFlapping {
+		t.Errorf("event = %s, want %s", event, EventBackendFlapping)
+	}
+}
@@ -0,0 +1,185 @@
+package hedge
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultDelay    = 100 * time.Millisecond
+	DefaultMaxRatio = 0.1
+
+	// latencyWindow is the number of recent latencies the percentile is taken
+	// from, and minSamples the number needed before it replaces Delay
+	latencyWindow = 512
+	minSamples    = 20
+
+	// The percentile is recomputed after this many new latencies
+	recomputeEvery = 32
+
+	// maxBudget caps the hedges saved up while traffic is low
+	maxBudget = 10.0
+)
+
+var DefaultMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+
+type Config struct {
+	// Delay before the hedged request is sent. With Percentile set, it is
+	// only used until enough latencies are known.
+	Delay      time.Duration
+	Percentile float64
+
+	// MaxRatio caps the hedged requests to this share of the requests
+	MaxRatio float64
+
+	// Methods that may be hedged. Only safe methods, which have no side
+	// effects, may be sent twice.
+	Methods []string
+}
+
+type Snapshot struct {
+	Delay     string  `json:"delay"`
+	MaxRatio  float64 `json:"max_ratio"`
+	Requests  uint64  `json:"requests"`
+	Hedged    uint64  `json:"hedged"`
+	HedgeWins uint64  `json:"hedge_wins"`
+	OverRatio uint64  `json:"over_ratio"`
+}
+
+// Policy decides when a second request is sent to another backend: after a
+// fixed delay or a percentile of recent latency, and only while the share of
+// hedged requests stays under the ratio.
+type Policy struct {
+	config       *Config
+	latencies    []time.Duration
+	next         int
+	sinceCompute int
+	delay        time.Duration
+	budget       float64
+	mutex        sync.Mutex
+	requests     uint64
+	hedged       uint64
+	hedgeWins    uint64
+	overRatio    uint64
+}
+
+func NewPolicy(config *Config) *Policy {
+	if config.Delay <= 0 {
+		config.Delay = DefaultDelay
+	}
+
+	if config.MaxRatio <= 0 {
+		config.MaxRatio = DefaultMaxRatio
+	}
+
+	if len(config.Methods) == 0 {
+		config.Methods = DefaultMethods
+	}
+
+	return &Policy{
+		config:    config,
+		latencies: make([]time.Duration, 0, latencyWindow),
+		delay:     config.Delay,
+	}
+}
+
+// Eligible reports whether the request may be hedged. Requests with a body
+// are never hedged, as the body can only be read once.
+func (p *Policy) Eligible(r *http.Request) bool {
+	if r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+		return false
+	}
+
+	return slices.ContainsFunc(p.config.Methods, func(method string) bool {
+		return strings.EqualFold(method, r.Method)
+	})
+}
+
+// Start counts an eligible request and adds its share to the hedge budget.
+func (p *Policy) Start() {
+	atomic.AddUint64(&p.requests, 1)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.budget = min(maxBudget, p.budget+p.config.MaxRatio)
+}
+
+func (p *Policy) Delay() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.delay
+}
+
+// Acquire takes a hedge from the budget. It returns false when hedging now
+// would exceed the ratio.
+func (p *Policy) Acquire() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.budget < 1 {
+		atomic.AddUint64(&p.overRatio, 1)
+		return false
+	}
+
+	p.budget -= 1
+	atomic.AddUint64(&p.hedged, 1)
+
+	return true
+}
+
+func (p *Policy) RecordHedgeWin() {
+	atomic.AddUint64(&p.hedgeWins, 1)
+}
+
+// Observe records the latency of the selected backend, used for the
+// percentile delay.
+func (p *Policy) Observe(latency time.Duration) {
+	if p.config.Percentile <= 0 {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.latencies) < latencyWindow {
+		p.latencies = append(p.latencies, latency)
+	} else {
+		p.latencies[p.next] = latency
+		p.next = (p.next + 1) % latencyWindow
+	}
+
+	p.sinceCompute += 1
+
+	if len(p.latencies) >= minSamples && p.sinceCompute >= recomputeEvery {
+		p.sinceCompute = 0
+		p.delay = percentile(p.latencies, p.config.Percentile)
+	}
+}
+
+// percentile returns the nearest-rank percentile of the latencies.
+func percentile(latencies []time.Duration, percent float64) time.Duration {
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	rank := int(math.Ceil(percent / 100 * float64(len(sorted))))
+
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+func (p *Policy) Snapshot() *Snapshot {
+	return &Snapshot{
+		Delay:     p.Delay().String(),
+		MaxRatio:  p.config.MaxRatio,
+		Requests:  atomic.LoadUint64(&p.requests),
+		Hedged:    atomic.LoadUint64(&p.hedged),
+		HedgeWins: atomic.LoadUint64(&p.hedgeWins),
+		OverRatio: atomic.LoadUint64(&p.overRatio),
+	}
+}
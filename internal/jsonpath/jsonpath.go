@@ -0,0 +1,35 @@
+package jsonpath
+
+import "strconv"
+
+// Lookup follows keys through a document decoded from JSON. Keys select
+// object fields, or elements of arrays by their index. It returns false when
+// a key is missing or the value is not an object or array.
+func Lookup(document any, keys []string) (any, bool) {
+	current := document
+
+	for _, key := range keys {
+		switch node := current.(type) {
+		case map[string]any:
+			value, exists := node[key]
+
+			if !exists {
+				return nil, false
+			}
+
+			current = value
+		case []any:
+			index, err := strconv.Atoi(key)
+
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
@@ -0,0 +1,104 @@
+package listeners
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// activity tracks the last time data moved in either direction, so a
+// connection is only considered idle when both halves are quiet.
+type activity struct {
+	lastActive atomic.Int64
+}
+
+func (a *activity) touch() {
+	a.lastActive.Store(time.Now().UnixNano())
+}
+
+func (a *activity) idleFor() time.Duration {
+	return time.Since(time.Unix(0, a.lastActive.Load()))
+}
+
+func copyHalf(dst net.Conn, src net.Conn, idleTimeout time.Duration, tracker *activity) error {
+	buffer := make([]byte, 32*1024)
+
+	for {
+		if idleTimeout > 0 {
+			src.SetReadDeadline(time.Now().Add(idleTimeout))
+		}
+
+		n, err := src.Read(buffer)
+
+		if n > 0 {
+			tracker.touch()
+
+			if _, writeErr := dst.Write(buffer[:n]); writeErr != nil {
+				return writeErr
+			}
+		}
+
+		if err == nil {
+			continue
+		}
+
+		if errors.Is(err, os.ErrDeadlineExceeded) && tracker.idleFor() < idleTimeout {
+			continue
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		return err
+	}
+}
+
+// pipe copies bytes in both directions until both sides are done. A clean EOF
+// on one side is forwarded as a half-close, any other error tears down both
+// connections.
+func pipe(client net.Conn, upstream net.Conn, idleTimeout time.Duration) {
+	tracker := &activity{}
+	tracker.touch()
+
+	var wg sync.WaitGroup
+	var closeOnce sync.Once
+
+	closeBoth := func() {
+		closeOnce.Do(func() {
+			client.Close()
+			upstream.Close()
+		})
+	}
+
+	forward := func(dst net.Conn, src net.Conn) {
+		defer wg.Done()
+
+		if err := copyHalf(dst, src, idleTimeout, tracker); err != nil {
+			closeBoth()
+			return
+		}
+
+		if cw, ok := dst.(closeWriter); ok {
+			cw.CloseWrite()
+		} else {
+			closeBoth()
+		}
+	}
+
+	wg.Add(2)
+
+	go forward(upstream, client)
+	go forward(client, upstream)
+
+	wg.Wait()
+	closeBoth()
+}
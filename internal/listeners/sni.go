@@ -0,0 +1,131 @@
+package listeners
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+)
+
+const (
+	ModeTLSPassthrough = "tls-passthrough"
+
+	DefaultClientHelloTimeout = 5 * time.Second
+)
+
+var errClientHelloRead = errors.New("client hello read")
+
+type SNIRouter struct {
+	exact               map[string]*loadbalancer.LoadBalancer
+	wildcard            map[string]*loadbalancer.LoadBalancer
+	defaultLoadBalancer *loadbalancer.LoadBalancer
+}
+
+func NewSNIRouter(defaultLB *loadbalancer.LoadBalancer) *SNIRouter {
+	return &SNIRouter{
+		exact:               make(map[string]*loadbalancer.LoadBalancer),
+		wildcard:            make(map[string]*loadbalancer.LoadBalancer),
+		defaultLoadBalancer: defaultLB,
+	}
+}
+
+// AddRoute maps a server name to a pool. A name starting with "*." matches
+// any direct subdomain.
+func (sr *SNIRouter) AddRoute(serverName string, lb *loadbalancer.LoadBalancer) {
+	serverName = strings.ToLower(serverName)
+
+	if suffix, ok := strings.CutPrefix(serverName, "*."); ok {
+		sr.wildcard[suffix] = lb
+		return
+	}
+
+	sr.exact[serverName] = lb
+}
+
+func (sr *SNIRouter) Match(serverName string) *loadbalancer.LoadBalancer {
+	serverName = strings.ToLower(serverName)
+
+	if lb, exists := sr.exact[serverName]; exists {
+		return lb
+	}
+
+	if _, parent, found := strings.Cut(serverName, "."); found {
+		if lb, exists := sr.wildcard[parent]; exists {
+			return lb
+		}
+	}
+
+	return sr.defaultLoadBalancer
+}
+
+// readOnlyConn feeds the TLS handshake with the client's bytes while making
+// sure nothing is ever written back, so the connection can be replayed.
+type readOnlyConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c readOnlyConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (c readOnlyConn) Write(b []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func (c readOnlyConn) Close() error {
+	return nil
+}
+
+// peekedConn replays the bytes consumed while reading the ClientHello before
+// reading from the underlying connection.
+type peekedConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (c *peekedConn) CloseWrite() error {
+	if cw, ok := c.Conn.(closeWriter); ok {
+		return cw.CloseWrite()
+	}
+
+	return c.Conn.Close()
+}
+
+// peekServerName reads the TLS ClientHello without terminating TLS, and
+// returns the requested server name together with a connection that replays
+// every byte read so far.
+func peekServerName(conn net.Conn, timeout time.Duration) (string, net.Conn, error) {
+	var consumed bytes.Buffer
+	var serverName string
+
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	err := tls.Server(readOnlyConn{Conn: conn, reader: io.TeeReader(conn, &consumed)}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			return nil, errClientHelloRead
+		},
+	}).Handshake()
+
+	replay := &peekedConn{
+		Conn:   conn,
+		reader: io.MultiReader(bytes.NewReader(consumed.Bytes()), conn),
+	}
+
+	if !errors.Is(err, errClientHelloRead) {
+		return "", replay, err
+	}
+
+	return serverName, replay, nil
+}
@@ -0,0 +1,234 @@
+package listeners
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+	"github.com/franciscodelahoz/load-balancer/internal/proxyproto"
+)
+
+const (
+	ModeTCP = "tcp"
+
+	DefaultIdleTimeout    = 5 * time.Minute
+	DefaultConnectTimeout = 5 * time.Second
+)
+
+type Config struct {
+	Name           string
+	Address        string
+	Mode           string
+	IdleTimeout    time.Duration
+	ConnectTimeout time.Duration
+	ProxyProtocol  bool
+	MaxSessions    int
+}
+
+type TCPProxy struct {
+	config       *Config
+	loadBalancer *loadbalancer.LoadBalancer
+	sniRouter    *SNIRouter
+	listener     net.Listener
+	connections  sync.WaitGroup
+	mutex        sync.Mutex
+	closed       bool
+}
+
+func (c *Config) applyDefaults() {
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = DefaultIdleTimeout
+	}
+
+	if c.ConnectTimeout == 0 {
+		c.ConnectTimeout = DefaultConnectTimeout
+	}
+}
+
+func NewTCPProxy(config *Config, lb *loadbalancer.LoadBalancer) *TCPProxy {
+	config.applyDefaults()
+
+	return &TCPProxy{
+		config:       config,
+		loadBalancer: lb,
+	}
+}
+
+// NewTLSPassthroughProxy returns a TCP proxy that picks the pool from the SNI
+// of the TLS ClientHello. TLS is not terminated, the raw connection is piped
+// to the backend.
+func NewTLSPassthroughProxy(config *Config, sniRouter *SNIRouter) *TCPProxy {
+	config.applyDefaults()
+
+	return &TCPProxy{
+		config:    config,
+		sniRouter: sniRouter,
+	}
+}
+
+func (p *TCPProxy) ListenAndServe() error {
+	listener, err := net.Listen("tcp", p.config.Address)
+
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", p.config.Address, err)
+	}
+
+	if p.config.ProxyProtocol {
+		listener = proxyproto.NewListener(listener, 0)
+	}
+
+	return p.Serve(listener)
+}
+
+func (p *TCPProxy) Serve(listener net.Listener) error {
+	p.mutex.Lock()
+	p.listener = listener
+	p.mutex.Unlock()
+
+	log.Printf("🔌 TCP listener %s running on %s", p.config.Name, listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+
+		if err != nil {
+			if p.isClosed() {
+				return nil
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+
+			return err
+		}
+
+		p.connections.Add(1)
+
+		go func() {
+			defer p.connections.Done()
+			p.handleConnection(conn)
+		}()
+	}
+}
+
+func (p *TCPProxy) isClosed() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.closed
+}
+
+func (p *TCPProxy) Close() error {
+	p.mutex.Lock()
+	p.closed = true
+	listener := p.listener
+	p.mutex.Unlock()
+
+	if listener == nil {
+		return nil
+	}
+
+	err := listener.Close()
+	p.connections.Wait()
+
+	return err
+}
+
+// dialBackend picks backends from the strategy until one accepts the
+// connection, trying each backend of the pool at most once.
+func dialBackend(lb *loadbalancer.LoadBalancer, client net.Conn, connectTimeout time.Duration) (*backend.Backend, net.Conn, error) {
+	tried := make(map[*backend.Backend]bool)
+
+	for {
+		selectedBackend := lb.GetNextBackendExcluding(nil, tried)
+
+		if selectedBackend == nil {
+			if len(tried) == 0 {
+				return nil, nil, fmt.Errorf("no healthy backends available")
+			}
+
+			return nil, nil, fmt.Errorf("all backends failed")
+		}
+
+		tried[selectedBackend] = true
+
+		upstream, err := dialUpstream(selectedBackend, client, connectTimeout)
+
+		if err == nil {
+			return selectedBackend, upstream, nil
+		}
+
+		log.Printf("❌ Error connecting to backend %s: %v", selectedBackend.URL.Host, err)
+
+		selectedBackend.IncrementErrorCount()
+		lb.OnRequestCompleted(selectedBackend)
+	}
+}
+
+func dialUpstream(b *backend.Backend, client net.Conn, connectTimeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: connectTimeout}
+	upstream, err := dialer.Dial("tcp", b.URL.Host)
+
+	if err != nil || b.ProxyProtocol == "" {
+		return upstream, err
+	}
+
+	if err := proxyproto.WriteHeader(upstream, b.ProxyProtocol, client.RemoteAddr(), client.LocalAddr()); err != nil {
+		upstream.Close()
+		return nil, err
+	}
+
+	return upstream, nil
+}
+
+func (p *TCPProxy) selectLoadBalancer(client net.Conn) (*loadbalancer.LoadBalancer, net.Conn, error) {
+	if p.sniRouter == nil {
+		return p.loadBalancer, client, nil
+	}
+
+	serverName, replay, err := peekServerName(client, DefaultClientHelloTimeout)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read TLS ClientHello: %w", err)
+	}
+
+	lb := p.sniRouter.Match(serverName)
+
+	if lb == nil {
+		return nil, nil, fmt.Errorf("no pool configured for server name %q", serverName)
+	}
+
+	return lb, replay, nil
+}
+
+func (p *TCPProxy) handleConnection(client net.Conn) {
+	defer client.Close()
+
+	lb, client, err := p.selectLoadBalancer(client)
+
+	if err != nil {
+		log.Printf("❌ TCP listener %s: %v", p.config.Name, err)
+		return
+	}
+
+	selectedBackend, upstream, err := dialBackend(lb, client, p.config.ConnectTimeout)
+
+	if err != nil {
+		log.Printf("❌ TCP listener %s: %v", p.config.Name, err)
+		return
+	}
+
+	selectedBackend.IncrementRequestsCount()
+
+	defer lb.OnRequestCompleted(selectedBackend)
+
+	log.Printf("🎯 %s -> %s (%s)", client.RemoteAddr(), selectedBackend.URL.Host, p.config.Mode)
+
+	pipe(client, upstream, p.config.IdleTimeout)
+}
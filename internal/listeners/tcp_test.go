@@ -0,0 +1,129 @@
+package listeners
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/proxyproto"
+)
+
+// tcpPair returns both ends of a loopback TCP connection.
+func tcpPair(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	defer listener.Close()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+
+	server, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	return client, server
+}
+
+func TestDialUpstreamSendsProxyProtocolHeader(t *testing.T) {
+	for _, version := range []string{proxyproto.Version1, proxyproto.Version2} {
+		t.Run(version, func(t *testing.T) {
+			upstreamListener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+
+			defer upstreamListener.Close()
+
+			b := backend.CreateBackendInstance(url.URL{Scheme: "tcp", Host: upstreamListener.Addr().String()}, 1, 0)
+			b.ProxyProtocol = version
+
+			// client is the balancer's side of an accepted connection
+			_, client := tcpPair(t)
+
+			upstream, err := dialUpstream(b, client, time.Second)
+			if err != nil {
+				t.Fatalf("dialUpstream: %v", err)
+			}
+
+			defer upstream.Close()
+
+			upstream.Write([]byte("payload"))
+
+			conn, err := upstreamListener.Accept()
+			if err != nil {
+				t.Fatalf("failed to accept: %v", err)
+			}
+
+			defer conn.Close()
+
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			reader := bufio.NewReader(conn)
+
+			header, err := proxyproto.ReadHeader(reader)
+			if err != nil {
+				t.Fatalf("ReadHeader: %v", err)
+			}
+
+			if header.Source.String() != client.RemoteAddr().String() || header.Destination.String() != client.LocalAddr().String() {
+				t.Errorf("got %s -> %s, want %s -> %s", header.Source, header.Destination, client.RemoteAddr(), client.LocalAddr())
+			}
+
+			payload := make([]byte, len("payload"))
+
+			if _, err := io.ReadFull(reader, payload); err != nil || string(payload) != "payload" {
+				t.Errorf("payload = %q (%v), want %q", payload, err, "payload")
+			}
+		})
+	}
+}
+
+func TestDialUpstreamWithoutProxyProtocol(t *testing.T) {
+	upstreamListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	defer upstreamListener.Close()
+
+	b := backend.CreateBackendInstance(url.URL{Scheme: "tcp", Host: upstreamListener.Addr().String()}, 1, 0)
+	_, client := tcpPair(t)
+
+	upstream, err := dialUpstream(b, client, time.Second)
+	if err != nil {
+		t.Fatalf("dialUpstream: %v", err)
+	}
+
+	upstream.Write([]byte("payload"))
+	upstream.Close()
+
+	conn, err := upstreamListener.Accept()
+	if err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	data, _ := io.ReadAll(conn)
+
+	if string(data) != "payload" {
+		t.Errorf("upstream read %q, want only the payload", data)
+	}
+}
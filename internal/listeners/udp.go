@@ -0,0 +1,343 @@
+package listeners
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+)
+
+const (
+	ModeUDP = "udp"
+
+	DefaultSessionTimeout = 30 * time.Second
+	DefaultMaxSessions    = 10000
+
+	maxDatagramSize = 65535
+)
+
+type udpSession struct {
+	clientAddr *net.UDPAddr
+	backend    *backend.Backend
+	upstream   *net.UDPConn
+	lastActive atomic.Int64
+}
+
+type UDPStats struct {
+	Sessions        int            `json:"sessions"`
+	MaxSessions     int            `json:"max_sessions"`
+	SessionTimeout  string         `json:"session_timeout"`
+	SessionsCreated uint64         `json:"sessions_created"`
+	SessionsExpired uint64         `json:"sessions_expired"`
+	SessionsDropped uint64         `json:"sessions_dropped"`
+	Backends        map[string]int `json:"backends"`
+}
+
+type UDPProxy struct {
+	config          *Config
+	loadBalancer    *loadbalancer.LoadBalancer
+	conn            *net.UDPConn
+	sessions        map[string]*udpSession
+	sessionsCreated atomic.Uint64
+	sessionsExpired atomic.Uint64
+	sessionsDropped atomic.Uint64
+	mutex           sync.Mutex
+	stopChannel     chan struct{}
+	closed          bool
+}
+
+func NewUDPProxy(config *Config, lb *loadbalancer.LoadBalancer) *UDPProxy {
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = DefaultSessionTimeout
+	}
+
+	if config.MaxSessions == 0 {
+		config.MaxSessions = DefaultMaxSessions
+	}
+
+	return &UDPProxy{
+		config:       config,
+		loadBalancer: lb,
+		sessions:     make(map[string]*udpSession),
+		stopChannel:  make(chan struct{}),
+	}
+}
+
+func (s *udpSession) touch() {
+	s.lastActive.Store(time.Now().UnixNano())
+}
+
+func (s *udpSession) key() string {
+	return s.clientAddr.String()
+}
+
+func (s *udpSession) idleFor() time.Duration {
+	return time.Since(time.Unix(0, s.lastActive.Load()))
+}
+
+func (p *UDPProxy) ListenAndServe() error {
+	address, err := net.ResolveUDPAddr("udp", p.config.Address)
+
+	if err != nil {
+		return fmt.Errorf("invalid listener address %s: %w", p.config.Address, err)
+	}
+
+	conn, err := net.ListenUDP("udp", address)
+
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", p.config.Address, err)
+	}
+
+	return p.Serve(conn)
+}
+
+func (p *UDPProxy) Serve(conn *net.UDPConn) error {
+	p.mutex.Lock()
+	p.conn = conn
+	p.mutex.Unlock()
+
+	log.Printf("🔌 UDP listener %s running on %s", p.config.Name, conn.LocalAddr())
+
+	go p.expirationLoop()
+
+	buffer := make([]byte, maxDatagramSize)
+
+	for {
+		n, clientAddr, err := conn.ReadFromUDP(buffer)
+
+		if err != nil {
+			if p.isClosed() || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		session := p.getOrCreateSession(clientAddr)
+
+		if session == nil {
+			continue
+		}
+
+		session.touch()
+
+		if _, err := session.upstream.Write(buffer[:n]); err != nil {
+			log.Printf("❌ Error forwarding datagram to backend %s: %v", session.backend.URL.Host, err)
+			session.backend.IncrementErrorCount()
+		}
+	}
+}
+
+// lookupSession returns the session of the client, or false when a new
+// session is needed. It returns nil and true when the session table is full.
+func (p *UDPProxy) lookupSession(key string) (*udpSession, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if session, exists := p.sessions[key]; exists {
+		return session, true
+	}
+
+	if len(p.sessions) >= p.config.MaxSessions {
+		p.sessionsDropped.Add(1)
+		return nil, true
+	}
+
+	return nil, false
+}
+
+// getOrCreateSession maps the client address to a backend. New sessions use
+// the pool's strategy and get their own upstream socket, so replies can be
+// routed back to the right client. The backend is resolved and dialed
+// without holding the session table lock.
+func (p *UDPProxy) getOrCreateSession(clientAddr *net.UDPAddr) *udpSession {
+	key := clientAddr.String()
+
+	if session, found := p.lookupSession(key); found {
+		return session
+	}
+
+	selectedBackend := p.loadBalancer.GetNextBackend(nil)
+
+	if selectedBackend == nil {
+		log.Printf("❌ UDP listener %s: no healthy backends available", p.config.Name)
+		return nil
+	}
+
+	upstream, err := dialUDPBackend(selectedBackend)
+
+	if err != nil {
+		log.Printf("❌ Error connecting to backend %s: %v", selectedBackend.URL.Host, err)
+
+		selectedBackend.IncrementErrorCount()
+		p.loadBalancer.OnRequestCompleted(selectedBackend)
+
+		return nil
+	}
+
+	session := &udpSession{
+		clientAddr: clientAddr,
+		backend:    selectedBackend,
+		upstream:   upstream,
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	existing, exists := p.sessions[key]
+
+	if exists || p.closed || len(p.sessions) >= p.config.MaxSessions {
+		// Another datagram created the session first, or the table filled up
+		// or closed while dialing
+		upstream.Close()
+		p.loadBalancer.OnRequestCompleted(selectedBackend)
+
+		if !exists && !p.closed {
+			p.sessionsDropped.Add(1)
+		}
+
+		return existing
+	}
+
+	session.touch()
+	p.sessions[key] = session
+	p.sessionsCreated.Add(1)
+
+	selectedBackend.IncrementRequestsCount()
+
+	go p.relayReplies(session)
+
+	log.Printf("🎯 %s -> %s (udp)", clientAddr, selectedBackend.URL.Host)
+
+	return session
+}
+
+func dialUDPBackend(b *backend.Backend) (*net.UDPConn, error) {
+	backendAddr, err := net.ResolveUDPAddr("udp", b.URL.Host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return net.DialUDP("udp", nil, backendAddr)
+}
+
+func (p *UDPProxy) relayReplies(session *udpSession) {
+	buffer := make([]byte, maxDatagramSize)
+
+	for {
+		n, err := session.upstream.Read(buffer)
+
+		if err != nil {
+			// Closed by session expiry, or the backend is unreachable. The
+			// latter leaves the session in the table, so the client's next
+			// datagram would go to a socket nobody reads from anymore.
+			p.mutex.Lock()
+
+			if current, exists := p.sessions[session.key()]; exists && current == session {
+				log.Printf("❌ Error reading from backend %s: %v", session.backend.URL.Host, err)
+
+				session.backend.IncrementErrorCount()
+				p.closeSession(session.key(), session)
+			}
+
+			p.mutex.Unlock()
+			return
+		}
+
+		session.touch()
+
+		if _, err := p.conn.WriteToUDP(buffer[:n], session.clientAddr); err != nil {
+			log.Printf("❌ Error relaying datagram to client %s: %v", session.clientAddr, err)
+		}
+	}
+}
+
+func (p *UDPProxy) expireSessions() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for key, session := range p.sessions {
+		if session.idleFor() >= p.config.IdleTimeout {
+			p.closeSession(key, session)
+			p.sessionsExpired.Add(1)
+		}
+	}
+}
+
+func (p *UDPProxy) closeSession(key string, session *udpSession) {
+	delete(p.sessions, key)
+
+	session.upstream.Close()
+	p.loadBalancer.OnRequestCompleted(session.backend)
+}
+
+func (p *UDPProxy) expirationLoop() {
+	ticker := time.NewTicker(max(time.Second, p.config.IdleTimeout/4))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.expireSessions()
+		case <-p.stopChannel:
+			return
+		}
+	}
+}
+
+func (p *UDPProxy) isClosed() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.closed
+}
+
+func (p *UDPProxy) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return nil
+	}
+
+	p.closed = true
+	close(p.stopChannel)
+
+	for key, session := range p.sessions {
+		p.closeSession(key, session)
+	}
+
+	if p.conn == nil {
+		return nil
+	}
+
+	return p.conn.Close()
+}
+
+func (p *UDPProxy) GetStats() *UDPStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := &UDPStats{
+		Sessions:        len(p.sessions),
+		MaxSessions:     p.config.MaxSessions,
+		SessionTimeout:  p.config.IdleTimeout.String(),
+		SessionsCreated: p.sessionsCreated.Load(),
+		SessionsExpired: p.sessionsExpired.Load(),
+		SessionsDropped: p.sessionsDropped.Load(),
+		Backends:        make(map[string]int),
+	}
+
+	for _, session := range p.sessions {
+		stats.Backends[session.backend.URL.Host] += 1
+	}
+
+	return stats
+}
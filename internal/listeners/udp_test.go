@@ -0,0 +1,210 @@
+package listeners
+
+import (
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+	"github.com/franciscodelahoz/load-balancer/internal/strategies"
+)
+
+// startEchoBackend runs a UDP server on loopback that sends every datagram
+// back to its sender.
+func startEchoBackend(t *testing.T) *net.UDPConn {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to start echo backend: %v", err)
+	}
+
+	go func() {
+		buffer := make([]byte, maxDatagramSize)
+
+		for {
+			n, addr, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+
+			conn.WriteToUDP(buffer[:n], addr)
+		}
+	}()
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func startUDPProxy(t *testing.T, config *Config, backendAddr net.Addr) (*UDPProxy, *backend.Backend) {
+	t.Helper()
+
+	b := backend.CreateBackendInstance(url.URL{Scheme: "udp", Host: backendAddr.String()}, 1, 0)
+
+	lb := loadbalancer.NewLoadBalancer(strategies.NewRoundRobinStrategy())
+	lb.AddBackend(b)
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	proxy := NewUDPProxy(config, lb)
+
+	go proxy.Serve(conn)
+
+	t.Cleanup(func() { proxy.Close() })
+
+	return proxy, b
+}
+
+func dialProxy(t *testing.T, proxy *UDPProxy) *net.UDPConn {
+	t.Helper()
+
+	// Serve sets the connection in its own goroutine
+	waitFor(t, func() bool { return listenerAddr(proxy) != nil })
+
+	client, err := net.DialUDP("udp", nil, listenerAddr(proxy))
+	if err != nil {
+		t.Fatalf("failed to dial proxy: %v", err)
+	}
+
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func listenerAddr(p *UDPProxy) *net.UDPAddr {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+
+	return p.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within 2s")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func roundTrip(t *testing.T, client *net.UDPConn, message string) {
+	t.Helper()
+
+	if _, err := client.Write([]byte(message)); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	buffer := make([]byte, maxDatagramSize)
+	n, err := client.Read(buffer)
+
+	if err != nil {
+		t.Fatalf("no reply: %v", err)
+	}
+
+	if string(buffer[:n]) != message {
+		t.Fatalf("reply = %q, want %q", buffer[:n], message)
+	}
+}
+
+func TestUDPProxyRelaysAndReusesSession(t *testing.T) {
+	echo := startEchoBackend(t)
+	proxy, b := startUDPProxy(t, &Config{Name: "test"}, echo.LocalAddr())
+	client := dialProxy(t, proxy)
+
+	roundTrip(t, client, "first")
+	roundTrip(t, client, "second")
+
+	stats := proxy.GetStats()
+
+	if stats.Sessions != 1 || stats.SessionsCreated != 1 {
+		t.Errorf("sessions = %d, created = %d, want 1 and 1", stats.Sessions, stats.SessionsCreated)
+	}
+
+	if active := b.GetActiveConnectionsCount(); active != 1 {
+		t.Errorf("active connections = %d, want 1", active)
+	}
+}
+
+func TestUDPProxyExpiresIdleSessions(t *testing.T) {
+	echo := startEchoBackend(t)
+	proxy, b := startUDPProxy(t, &Config{Name: "test", IdleTimeout: 50 * time.Millisecond}, echo.LocalAddr())
+	client := dialProxy(t, proxy)
+
+	roundTrip(t, client, "ping")
+
+	time.Sleep(100 * time.Millisecond)
+	proxy.expireSessions()
+
+	stats := proxy.GetStats()
+
+	if stats.Sessions != 0 || stats.SessionsExpired != 1 {
+		t.Errorf("sessions = %d, expired = %d, want 0 and 1", stats.Sessions, stats.SessionsExpired)
+	}
+
+	if active := b.GetActiveConnectionsCount(); active != 0 {
+		t.Errorf("active connections = %d, want 0", active)
+	}
+
+	// The next datagram starts a new session
+	roundTrip(t, client, "again")
+
+	if created := proxy.GetStats().SessionsCreated; created != 2 {
+		t.Errorf("sessions created = %d, want 2", created)
+	}
+}
+
+func TestUDPProxyDropsSessionWhenBackendIsGone(t *testing.T) {
+	echo := startEchoBackend(t)
+	proxy, b := startUDPProxy(t, &Config{Name: "test"}, echo.LocalAddr())
+	client := dialProxy(t, proxy)
+
+	roundTrip(t, client, "ping")
+	echo.Close()
+
+	// The datagram is refused by the closed port, which fails the read of
+	// the session's upstream socket
+	client.Write([]byte("lost"))
+
+	waitFor(t, func() bool { return proxy.GetStats().Sessions == 0 })
+
+	if active := b.GetActiveConnectionsCount(); active != 0 {
+		t.Errorf("active connections = %d, want 0", active)
+	}
+
+	if errors := b.GetErrorCount(); errors == 0 {
+		t.Errorf("error count = 0, want the read error counted")
+	}
+}
+
+func TestUDPProxyDropsClientsOverMaxSessions(t *testing.T) {
+	echo := startEchoBackend(t)
+	proxy, _ := startUDPProxy(t, &Config{Name: "test", MaxSessions: 1}, echo.LocalAddr())
+
+	roundTrip(t, dialProxy(t, proxy), "first")
+
+	second := dialProxy(t, proxy)
+	second.Write([]byte("second"))
+
+	waitFor(t, func() bool { return proxy.GetStats().SessionsDropped == 1 })
+
+	if sessions := proxy.GetStats().Sessions; sessions != 1 {
+		t.Errorf("sessions = %d, want 1", sessions)
+	}
+}
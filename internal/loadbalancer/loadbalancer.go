@@ -26,17 +26,51 @@ func (lb *LoadBalancer) AddBackend(backend *backend.Backend) {
 	lb.mutex.Lock()
 	defer lb.mutex.Unlock()
 
-	lb.serverPool.AddBackend(backend)
-
+	// Registered first, so a pessimistic health check marks the backend down
+	// before any request can be sent to it
 	if lb.health != nil {
 		lb.health.RegisterBackend(backend)
 	}
 
+	lb.serverPool.AddBackend(backend)
+
 	if eventAware, ok := lb.strategy.(BackendEventAware); ok {
 		eventAware.OnBackendAdded(backend)
 	}
 }
 
+func (lb *LoadBalancer) RemoveBackend(backend *backend.Backend) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	lb.serverPool.RemoveBackend(backend)
+
+	if lb.health != nil {
+		lb.health.UnregisterBackend(backend)
+	}
+
+	if eventAware, ok := lb.strategy.(BackendEventAware); ok {
+		eventAware.OnBackendRemoved(backend)
+	}
+}
+
+func (lb *LoadBalancer) SetBackendWeight(backend *backend.Backend, weight uint64) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	oldWeight := backend.GetWeight()
+
+	if oldWeight == weight {
+		return
+	}
+
+	backend.SetWeight(weight)
+
+	if eventAware, ok := lb.strategy.(BackendEventAware); ok {
+		eventAware.OnBackendWeightChanged(backend, oldWeight, weight)
+	}
+}
+
 func (lb *LoadBalancer) StartHealthChecking(config health.Config) {
 	lb.health = health.NewHealthChecker(&config)
 
@@ -67,6 +101,28 @@ func (lb *LoadBalancer) GetNextBackend(r *http.Request) *backend.Backend {
 	return selectedBackend
 }
 
+// GetNextBackendExcluding is used for retries. When the strategy returns an
+// excluded backend, it picks again over the pool without the excluded
+// backends, so weights, stickiness and zones still apply.
+func (lb *LoadBalancer) GetNextBackendExcluding(r *http.Request, excluded map[*backend.Backend]bool) *backend.Backend {
+	selectedBackend := lb.GetNextBackend(r)
+
+	if selectedBackend == nil || !excluded[selectedBackend] {
+		return selectedBackend
+	}
+
+	lb.OnRequestCompleted(selectedBackend)
+
+	remaining := lb.serverPool.Filter(func(b *backend.Backend) bool { return !excluded[b] })
+	selectedBackend = lb.strategy.GetNextBackend(remaining, r)
+
+	if selectedBackend != nil {
+		selectedBackend.IncrementActiveConnections()
+	}
+
+	return selectedBackend
+}
+
 func (lb *LoadBalancer) OnRequestCompleted(backend *backend.Backend) {
 	if backend != nil {
 		backend.DecrementActiveConnections()
@@ -76,3 +132,33 @@ func (lb *LoadBalancer) OnRequestCompleted(backend *backend.Backend) {
 func (lb *LoadBalancer) GetStrategyName() string {
 	return lb.strategy.GetStrategyName()
 }
+
+func (lb *LoadBalancer) GetHealthResults() map[string]*health.Result {
+	if lb.health == nil {
+		return map[string]*health.Result{}
+	}
+
+	return lb.health.GetResults()
+}
+
+func (lb *LoadBalancer) GetHealthHistory() map[string]*health.BackendHistory {
+	if lb.health == nil {
+		return map[string]*health.BackendHistory{}
+	}
+
+	return lb.health.GetHistory()
+}
+
+// SubscribeHealthEvents subscribes to the events of the pool's health checker.
+// Without health checking the returned channel is nil.
+func (lb *LoadBalancer) SubscribeHealthEvents(buffer int) (<-chan health.Event, func()) {
+	if lb.health == nil {
+		return nil, func() {}
+	}
+
+	return lb.health.Subscribe(buffer)
+}
+
+func (lb *LoadBalancer) GetAllBackends() []*backend.Backend {
+	return lb.serverPool.GetAllBackends()
+}
@@ -24,12 +24,14 @@ func (pool *ServerPool) AddBackend(b *backend.Backend) {
 	pool.backends = append(pool.backends, b)
 }
 
-func (pool *ServerPool) RemoveBackend(backendURL string) {
+// RemoveBackend removes b from the pool. Backends are matched by identity,
+// as static backends and discovery providers may share a URL.
+func (pool *ServerPool) RemoveBackend(b *backend.Backend) {
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
 	for i, backend := range pool.backends {
-		if backend.URL.String() == backendURL {
+		if backend == b {
 			pool.backends = append(pool.backends[:i], pool.backends[i+1:]...)
 			break
 		}
@@ -60,3 +62,20 @@ func (pool *ServerPool) GetAliveBackends() []*backend.Backend {
 
 	return aliveBackends
 }
+
+// Filter returns a pool holding the backends for which keep returns true.
+// Strategies that wrap another strategy use it to narrow the candidates.
+func (pool *ServerPool) Filter(keep func(b *backend.Backend) bool) *ServerPool {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	filtered := NewServerPool()
+
+	for _, backend := range pool.backends {
+		if keep(backend) {
+			filtered.backends = append(filtered.backends, backend)
+		}
+	}
+
+	return filtered
+}
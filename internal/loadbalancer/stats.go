@@ -0,0 +1,64 @@
+package loadbalancer
+
+import "github.com/franciscodelahoz/load-balancer/internal/concurrency"
+
+type BackendStats struct {
+	URL               string                `json:"url"`
+	Alive             bool                  `json:"alive"`
+	Labels            map[string]string     `json:"labels,omitempty"`
+	Weight            uint64                `json:"weight"`
+	Priority          int                   `json:"priority"`
+	Requests          uint64                `json:"requests"`
+	Errors            uint64                `json:"errors"`
+	ActiveConnections uint64                `json:"active_connections"`
+	Concurrency       *concurrency.Snapshot `json:"concurrency,omitempty"`
+}
+
+type Stats struct {
+	Strategy       string          `json:"strategy"`
+	ActivePriority *int            `json:"active_priority,omitempty"`
+	PriorityLevels []PriorityLevel `json:"priority_levels,omitempty"`
+	Backends       []BackendStats  `json:"backends"`
+}
+
+func (lb *LoadBalancer) GetStats() *Stats {
+	backends := lb.serverPool.GetAllBackends()
+
+	stats := &Stats{
+		Strategy: lb.GetStrategyName(),
+		Backends: make([]BackendStats, 0, len(backends)),
+	}
+
+	for _, b := range backends {
+		backendStats := BackendStats{
+			URL:               b.URL.String(),
+			Alive:             b.IsAlive(),
+			Labels:            b.GetLabels(),
+			Weight:            b.GetWeight(),
+			Priority:          b.Priority,
+			Requests:          b.GetRequestsCount(),
+			Errors:            b.GetErrorCount(),
+			ActiveConnections: b.GetActiveConnectionsCount(),
+		}
+
+		if b.ConcurrencyLimiter != nil {
+			backendStats.Concurrency = b.ConcurrencyLimiter.Snapshot()
+		}
+
+		stats.Backends = append(stats.Backends, backendStats)
+	}
+
+	if reporter, ok := lb.strategy.(PriorityReporter); ok {
+		stats.PriorityLevels = reporter.GetPriorityLevels(lb.serverPool)
+
+		// The active level is the most preferred one that receives requests
+		for _, level := range stats.PriorityLevels {
+			if level.Load > 0 {
+				stats.ActivePriority = &level.Priority
+				break
+			}
+		}
+	}
+
+	return stats
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/franciscodelahoz/load-balancer/internal/backend"
 )
 
+// LoadBalancerStrategy picks the backend for a request. The request is nil
+// when the strategy is used by layer 4 (TCP) listeners.
 type LoadBalancerStrategy interface {
 	GetNextBackend(pool *ServerPool, r *http.Request) *backend.Backend
 	GetStrategyName() string
@@ -16,3 +18,18 @@ type BackendEventAware interface {
 	OnBackendRemoved(backend *backend.Backend)
 	OnBackendWeightChanged(backend *backend.Backend, oldWeight, newWeight uint64)
 }
+
+// PriorityLevel is the state of one priority level of a pool. Load is the
+// share of requests the level currently receives.
+type PriorityLevel struct {
+	Priority int     `json:"priority"`
+	Healthy  int     `json:"healthy"`
+	Total    int     `json:"total"`
+	Load     float64 `json:"load"`
+}
+
+// PriorityReporter is implemented by strategies that spread requests over
+// priority levels, so the levels can be reported in the stats.
+type PriorityReporter interface {
+	GetPriorityLevels(pool *ServerPool) []PriorityLevel
+}
@@ -0,0 +1,304 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+)
+
+const (
+	DefaultMaxBodySize = 1024 * 1024
+	DefaultTimeout     = 5 * time.Second
+	DefaultMaxInFlight = 100
+)
+
+type Config struct {
+	Pool string
+
+	// Percent of the requests that are mirrored, from 0 to 100
+	Percent float64
+
+	// Requests with a larger body are not mirrored
+	MaxBodySize int64
+	Timeout     time.Duration
+
+	// Mirrored requests beyond MaxInFlight are dropped, so a slow shadow pool
+	// cannot pile up goroutines and buffered bodies
+	MaxInFlight int
+}
+
+type Snapshot struct {
+	Pool           string  `json:"pool"`
+	Percent        float64 `json:"percent"`
+	Mirrored       uint64  `json:"mirrored"`
+	Failed         uint64  `json:"failed"`
+	Timeouts       uint64  `json:"timeouts"`
+	Dropped        uint64  `json:"dropped"`
+	BodyTooLarge   uint64  `json:"body_too_large"`
+	InFlight       int64   `json:"in_flight"`
+	AverageLatency string  `json:"average_latency"`
+}
+
+// Mirror sends a copy of a share of the requests to a shadow pool. Copies are
+// sent in the background and their responses are discarded, so they never
+// affect the response or latency of the client.
+type Mirror struct {
+	config       *Config
+	loadBalancer *loadbalancer.LoadBalancer
+	mirrored     uint64
+	failed       uint64
+	timeouts     uint64
+	dropped      uint64
+	bodyTooLarge uint64
+	inFlight     int64
+	latencySum   int64
+}
+
+func NewMirror(config *Config, loadBalancer *loadbalancer.LoadBalancer) *Mirror {
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultMaxBodySize
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = DefaultMaxInFlight
+	}
+
+	return &Mirror{
+		config:       config,
+		loadBalancer: loadBalancer,
+	}
+}
+
+// teeBody copies the request body as the primary request reads it, so the
+// client's upload is not delayed by the mirror. The shadow request is sent
+// once the whole body was read, and given up as soon as the body exceeds the
+// limit.
+type teeBody struct {
+	io.ReadCloser
+	mirror *Mirror
+	shadow *http.Request
+	buffer bytes.Buffer
+	done   bool
+	mutex  sync.Mutex
+}
+
+func (tb *teeBody) Read(p []byte) (int, error) {
+	n, err := tb.ReadCloser.Read(p)
+
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	if tb.done {
+		return n, err
+	}
+
+	if int64(tb.buffer.Len()+n) > tb.mirror.config.MaxBodySize {
+		tb.done = true
+		tb.buffer = bytes.Buffer{}
+		tb.mirror.release(&tb.mirror.bodyTooLarge)
+
+		return n, err
+	}
+
+	tb.buffer.Write(p[:n])
+
+	// A reader may stop at the declared length without waiting for EOF
+	complete := tb.shadow.ContentLength > 0 && int64(tb.buffer.Len()) == tb.shadow.ContentLength
+
+	if errors.Is(err, io.EOF) || complete {
+		tb.done = true
+		go tb.mirror.dispatch(tb.shadow, tb.buffer.Bytes())
+	}
+
+	return n, err
+}
+
+// finish gives up the shadow request when the primary request ended without
+// reading the whole body.
+func (tb *teeBody) finish() {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	if !tb.done {
+		tb.done = true
+		tb.mirror.release(&tb.mirror.dropped)
+	}
+}
+
+// release frees the in-flight slot of a copy that is not sent and counts it.
+func (m *Mirror) release(counter *uint64) {
+	atomic.AddInt64(&m.inFlight, -1)
+	atomic.AddUint64(counter, 1)
+}
+
+func (m *Mirror) dispatch(shadow *http.Request, body []byte) {
+	defer atomic.AddInt64(&m.inFlight, -1)
+	m.send(shadow, body)
+}
+
+func (m *Mirror) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rand.Float64()*100 >= m.config.Percent {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Capacity is taken first, so copies that would be dropped never
+		// buffer anything
+		if atomic.AddInt64(&m.inFlight, 1) > int64(m.config.MaxInFlight) {
+			m.release(&m.dropped)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.ContentLength > m.config.MaxBodySize {
+			m.release(&m.bodyTooLarge)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The copy is taken before the primary request is served, as the
+		// proxy may change the original
+		shadow := r.Clone(context.Background())
+
+		if r.Body == nil || r.Body == http.NoBody {
+			go m.dispatch(shadow, nil)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body := &teeBody{ReadCloser: r.Body, mirror: m, shadow: shadow}
+		r.Body = body
+
+		next.ServeHTTP(w, r)
+		body.finish()
+	})
+}
+
+// discardResponseWriter drops shadow responses. Their status is checked in
+// ModifyResponse.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d *discardResponseWriter) Header() http.Header {
+	return d.header
+}
+
+func (d *discardResponseWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (d *discardResponseWriter) WriteHeader(status int) {}
+
+func (m *Mirror) send(shadow *http.Request, body []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.Timeout)
+	defer cancel()
+
+	shadow = shadow.WithContext(ctx)
+	shadow.Body = io.NopCloser(bytes.NewReader(body))
+	shadow.ContentLength = int64(len(body))
+
+	if body == nil {
+		shadow.Body = http.NoBody
+	}
+
+	selectedBackend := m.loadBalancer.GetNextBackend(shadow)
+
+	if selectedBackend == nil {
+		atomic.AddUint64(&m.failed, 1)
+		return
+	}
+
+	defer m.loadBalancer.OnRequestCompleted(selectedBackend)
+
+	selectedBackend.IncrementRequestsCount()
+
+	start := time.Now()
+	failed := m.proxy(selectedBackend, shadow)
+
+	atomic.AddInt64(&m.latencySum, int64(time.Since(start)))
+	atomic.AddUint64(&m.mirrored, 1)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		atomic.AddUint64(&m.timeouts, 1)
+	} else if failed {
+		atomic.AddUint64(&m.failed, 1)
+	}
+}
+
+func (m *Mirror) proxy(b *backend.Backend, shadow *http.Request) bool {
+	failed := false
+
+	// Same as the proxy handler: the shared ReverseProxy is not modified
+	proxy := &httputil.ReverseProxy{
+		Transport: b.ReverseProxy.Transport,
+	}
+
+	proxy.Director = func(req *http.Request) {
+		originalHost := req.Host
+
+		b.ReverseProxy.Director(req)
+		req.Host = b.URL.Host
+		req.Header.Set("X-Forwarded-Host", originalHost)
+	}
+
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("❌ Mirror error for backend %s: %v", b.URL.String(), err)
+
+		b.IncrementErrorCount()
+		failed = true
+	}
+
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		if resp.StatusCode >= 400 {
+			b.IncrementErrorCount()
+		}
+
+		if resp.StatusCode >= 500 {
+			failed = true
+		}
+
+		return nil
+	}
+
+	proxy.ServeHTTP(&discardResponseWriter{header: make(http.Header)}, shadow)
+
+	return failed
+}
+
+func (m *Mirror) Snapshot() *Snapshot {
+	mirrored := atomic.LoadUint64(&m.mirrored)
+	averageLatency := time.Duration(0)
+
+	if mirrored > 0 {
+		averageLatency = time.Duration(atomic.LoadInt64(&m.latencySum) / int64(mirrored))
+	}
+
+	return &Snapshot{
+		Pool:           m.config.Pool,
+		Percent:        m.config.Percent,
+		Mirrored:       mirrored,
+		Failed:         atomic.LoadUint64(&m.failed),
+		Timeouts:       atomic.LoadUint64(&m.timeouts),
+		Dropped:        atomic.LoadUint64(&m.dropped),
+		BodyTooLarge:   atomic.LoadUint64(&m.bodyTooLarge),
+		InFlight:       atomic.LoadInt64(&m.inFlight),
+		AverageLatency: averageLatency.String(),
+	}
+}
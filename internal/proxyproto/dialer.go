@@ -0,0 +1,62 @@
+package proxyproto
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+type sourceAddrKey struct{}
+
+func WithSourceAddr(ctx context.Context, source net.Addr) context.Context {
+	return context.WithValue(ctx, sourceAddrKey{}, source)
+}
+
+func SourceAddrFromContext(ctx context.Context) net.Addr {
+	source, _ := ctx.Value(sourceAddrKey{}).(net.Addr)
+	return source
+}
+
+// Dial connects to address and writes a PROXY protocol header carrying the
+// source stored in ctx and the local address of the incoming connection,
+// falling back to the upstream address when ctx has no local address.
+// Without a known source a LOCAL (v2) or UNKNOWN (v1) header is sent.
+func Dial(ctx context.Context, dialer *net.Dialer, version string, network string, address string) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, network, address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	destination, ok := ctx.Value(http.LocalAddrContextKey).(net.Addr)
+
+	if !ok {
+		destination = conn.RemoteAddr()
+	}
+
+	if err := WriteHeader(conn, version, SourceAddrFromContext(ctx), destination); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// NewTransport returns an HTTP transport that sends a PROXY protocol header on
+// every upstream connection. Keep-alives are disabled because a pooled
+// connection would carry the header of whichever client opened it.
+func NewTransport(version string) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = true
+	transport.DialContext = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		return Dial(ctx, dialer, version, network, address)
+	}
+
+	return transport
+}
@@ -0,0 +1,158 @@
+package proxyproto
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type receivedConn struct {
+	header  *Header
+	payload string
+	err     error
+}
+
+// startHeaderBackend accepts one connection on loopback and reports the PROXY
+// protocol header and the payload that followed it.
+func startHeaderBackend(t *testing.T, payloadSize int) (net.Listener, <-chan receivedConn) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan receivedConn, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- receivedConn{err: err}
+			return
+		}
+
+		defer conn.Close()
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		reader := bufio.NewReader(conn)
+
+		header, err := ReadHeader(reader)
+		if err != nil {
+			received <- receivedConn{err: err}
+			return
+		}
+
+		payload := make([]byte, payloadSize)
+		_, err = io.ReadFull(reader, payload)
+
+		received <- receivedConn{header: header, payload: string(payload), err: err}
+	}()
+
+	return listener, received
+}
+
+func TestDialWritesHeaderBeforePayload(t *testing.T) {
+	source := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324}
+	destination := &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 443}
+
+	for _, version := range []string{Version1, Version2} {
+		t.Run(version, func(t *testing.T) {
+			listener, received := startHeaderBackend(t, len("payload"))
+
+			ctx := WithSourceAddr(context.Background(), source)
+			ctx = context.WithValue(ctx, http.LocalAddrContextKey, net.Addr(destination))
+
+			conn, err := Dial(ctx, &net.Dialer{}, version, "tcp", listener.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+
+			defer conn.Close()
+
+			conn.Write([]byte("payload"))
+
+			result := <-received
+
+			if result.err != nil {
+				t.Fatalf("backend: %v", result.err)
+			}
+
+			if result.header.Version != version {
+				t.Errorf("version = %s, want %s", result.header.Version, version)
+			}
+
+			if result.header.Source.String() != source.String() || result.header.Destination.String() != destination.String() {
+				t.Errorf("got %s -> %s, want %s -> %s", result.header.Source, result.header.Destination, source, destination)
+			}
+
+			if result.payload != "payload" {
+				t.Errorf("payload = %q, want %q", result.payload, "payload")
+			}
+		})
+	}
+}
+
+func TestDialWithoutSourceSendsLocalHeader(t *testing.T) {
+	listener, received := startHeaderBackend(t, 0)
+
+	conn, err := Dial(context.Background(), &net.Dialer{}, Version2, "tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+
+	defer conn.Close()
+
+	result := <-received
+
+	if result.err != nil {
+		t.Fatalf("backend: %v", result.err)
+	}
+
+	if !result.header.Local {
+		t.Errorf("header.Local = false, want a LOCAL header without a source")
+	}
+}
+
+func TestTransportSendsHeaderOnEveryConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	// The backend sees the client address of the header as the remote
+	// address of the request
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.RemoteAddr))
+		}),
+	}
+
+	go server.Serve(NewListener(listener, time.Second))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(Version1)}
+
+	for _, source := range []string{"192.0.2.1:1000", "192.0.2.2:2000"} {
+		sourceAddr, _ := net.ResolveTCPAddr("tcp", source)
+
+		req, _ := http.NewRequestWithContext(WithSourceAddr(context.Background(), sourceAddr), http.MethodGet, "http://"+listener.Addr().String(), nil)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(body) != source {
+			t.Errorf("backend saw %s, want %s", body, source)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	Version1 = "v1"
+	Version2 = "v2"
+
+	v1Prefix    = "PROXY "
+	v1MaxLength = 107
+
+	v2CommandLocal = 0x0
+	v2CommandProxy = 0x1
+
+	v2FamilyTCP4 = 0x11
+	v2FamilyUDP4 = 0x12
+	v2FamilyTCP6 = 0x21
+	v2FamilyUDP6 = 0x22
+)
+
+var v2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+var ErrNoHeader = errors.New("proxy protocol header not found")
+
+type Header struct {
+	Version     string
+	Local       bool
+	Source      net.Addr
+	Destination net.Addr
+}
+
+func ReadHeader(reader *bufio.Reader) (*Header, error) {
+	signature, err := reader.Peek(len(v2Signature))
+
+	if err == nil && bytes.Equal(signature, v2Signature) {
+		return readV2Header(reader)
+	}
+
+	prefix, err := reader.Peek(len(v1Prefix))
+
+	if err == nil && string(prefix) == v1Prefix {
+		return readV1Header(reader)
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return nil, ErrNoHeader
+}
+
+func readV1Header(reader *bufio.Reader) (*Header, error) {
+	var line []byte
+
+	for len(line) < v1MaxLength {
+		b, err := reader.ReadByte()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read v1 header: %w", err)
+		}
+
+		line = append(line, b)
+
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			break
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("v1 header exceeds %d bytes", v1MaxLength)
+	}
+
+	fields := strings.Fields(strings.TrimSuffix(string(line), "\r\n"))
+
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return &Header{Version: Version1, Local: true}, nil
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("malformed v1 header: %q", line)
+	}
+
+	source, err := parseV1Address(fields[2], fields[4])
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := parseV1Address(fields[3], fields[5])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Header{
+		Version:     Version1,
+		Source:      source,
+		Destination: destination,
+	}, nil
+}
+
+func parseV1Address(host string, port string) (*net.TCPAddr, error) {
+	ip := net.ParseIP(host)
+
+	if ip == nil {
+		return nil, fmt.Errorf("invalid v1 header address: %s", host)
+	}
+
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid v1 header port: %s", port)
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(portNumber)}, nil
+}
+
+func readV2Header(reader *bufio.Reader) (*Header, error) {
+	fixed := make([]byte, 16)
+
+	if _, err := io.ReadFull(reader, fixed); err != nil {
+		return nil, fmt.Errorf("failed to read v2 header: %w", err)
+	}
+
+	if fixed[12]>>4 != 0x2 {
+		return nil, fmt.Errorf("unsupported v2 header version: %d", fixed[12]>>4)
+	}
+
+	command := fixed[12] & 0x0F
+	family := fixed[13]
+	length := binary.BigEndian.Uint16(fixed[14:16])
+
+	payload := make([]byte, length)
+
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, fmt.Errorf("failed to read v2 header addresses: %w", err)
+	}
+
+	header := &Header{Version: Version2}
+
+	switch command {
+	case v2CommandLocal:
+		header.Local = true
+		return header, nil
+	case v2CommandProxy:
+	default:
+		return nil, fmt.Errorf("unsupported v2 header command: %d", command)
+	}
+
+	switch family {
+	case v2FamilyTCP4, v2FamilyUDP4:
+		if len(payload) < 12 {
+			return nil, fmt.Errorf("v2 header too short for IPv4 addresses")
+		}
+
+		header.Source, header.Destination = v2Addresses(family, payload[0:4], payload[4:8], payload[8:12])
+	case v2FamilyTCP6, v2FamilyUDP6:
+		if len(payload) < 36 {
+			return nil, fmt.Errorf("v2 header too short for IPv6 addresses")
+		}
+
+		header.Source, header.Destination = v2Addresses(family, payload[0:16], payload[16:32], payload[32:36])
+	default:
+		// Unix sockets and unspecified families carry no usable address
+		header.Local = true
+	}
+
+	return header, nil
+}
+
+func v2Addresses(family byte, source []byte, destination []byte, ports []byte) (net.Addr, net.Addr) {
+	sourceIP := net.IP(bytes.Clone(source))
+	destinationIP := net.IP(bytes.Clone(destination))
+	sourcePort := int(binary.BigEndian.Uint16(ports[0:2]))
+	destinationPort := int(binary.BigEndian.Uint16(ports[2:4]))
+
+	if family == v2FamilyUDP4 || family == v2FamilyUDP6 {
+		return &net.UDPAddr{IP: sourceIP, Port: sourcePort}, &net.UDPAddr{IP: destinationIP, Port: destinationPort}
+	}
+
+	return &net.TCPAddr{IP: sourceIP, Port: sourcePort}, &net.TCPAddr{IP: destinationIP, Port: destinationPort}
+}
+
+func splitAddr(addr net.Addr) (net.IP, int, bool) {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP, a.Port, true
+	case *net.UDPAddr:
+		return a.IP, a.Port, true
+	}
+
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil, 0, false
+	}
+
+	ip := net.ParseIP(host)
+	portNumber, err := strconv.Atoi(port)
+
+	return ip, portNumber, ip != nil && err == nil
+}
+
+func (h *Header) Format() ([]byte, error) {
+	switch h.Version {
+	case Version1:
+		return h.formatV1(), nil
+	case Version2:
+		return h.formatV2(), nil
+	default:
+		return nil, fmt.Errorf("unknown proxy protocol version: %s", h.Version)
+	}
+}
+
+func (h *Header) addresses() (net.IP, int, net.IP, int, bool) {
+	if h.Local || h.Source == nil || h.Destination == nil {
+		return nil, 0, nil, 0, false
+	}
+
+	sourceIP, sourcePort, ok := splitAddr(h.Source)
+	if !ok {
+		return nil, 0, nil, 0, false
+	}
+
+	destinationIP, destinationPort, ok := splitAddr(h.Destination)
+	if !ok {
+		return nil, 0, nil, 0, false
+	}
+
+	// Both addresses must belong to the same family
+	if (sourceIP.To4() == nil) != (destinationIP.To4() == nil) {
+		return nil, 0, nil, 0, false
+	}
+
+	return sourceIP, sourcePort, destinationIP, destinationPort, true
+}
+
+func (h *Header) formatV1() []byte {
+	sourceIP, sourcePort, destinationIP, destinationPort, ok := h.addresses()
+
+	if !ok {
+		return []byte("PROXY UNKNOWN\r\n")
+	}
+
+	protocol := "TCP6"
+	if sourceIP.To4() != nil {
+		protocol = "TCP4"
+	}
+
+	return fmt.Appendf(nil, "PROXY %s %s %s %d %d\r\n", protocol, sourceIP, destinationIP, sourcePort, destinationPort)
+}
+
+func (h *Header) formatV2() []byte {
+	buffer := bytes.NewBuffer(bytes.Clone(v2Signature))
+
+	sourceIP, sourcePort, destinationIP, destinationPort, ok := h.addresses()
+
+	if !ok {
+		buffer.Write([]byte{0x20 | v2CommandLocal, 0x00, 0x00, 0x00})
+		return buffer.Bytes()
+	}
+
+	_, udp := h.Source.(*net.UDPAddr)
+
+	var family byte
+	var addressBytes []byte
+
+	if sourceIP.To4() != nil {
+		family = v2FamilyTCP4
+		addressBytes = append(bytes.Clone(sourceIP.To4()), destinationIP.To4()...)
+	} else {
+		family = v2FamilyTCP6
+		addressBytes = append(bytes.Clone(sourceIP.To16()), destinationIP.To16()...)
+	}
+
+	if udp {
+		family += 1
+	}
+
+	addressBytes = binary.BigEndian.AppendUint16(addressBytes, uint16(sourcePort))
+	addressBytes = binary.BigEndian.AppendUint16(addressBytes, uint16(destinationPort))
+
+	buffer.Write([]byte{0x20 | v2CommandProxy, family})
+	buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(len(addressBytes))))
+	buffer.Write(addressBytes)
+
+	return buffer.Bytes()
+}
+
+func WriteHeader(w io.Writer, version string, source net.Addr, destination net.Addr) error {
+	header := &Header{
+		Version:     version,
+		Source:      source,
+		Destination: destination,
+	}
+
+	data, err := header.Format()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,244 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadHeaderV1(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		source      string
+		destination string
+		local       bool
+	}{
+		{
+			name:        "tcp4",
+			input:       "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n",
+			source:      "192.0.2.1:56324",
+			destination: "198.51.100.1:443",
+		},
+		{
+			name:        "tcp6",
+			input:       "PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n",
+			source:      "[2001:db8::1]:56324",
+			destination: "[2001:db8::2]:443",
+		},
+		{
+			name:  "unknown",
+			input: "PROXY UNKNOWN\r\n",
+			local: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(test.input + "payload"))
+
+			header, err := ReadHeader(reader)
+			if err != nil {
+				t.Fatalf("ReadHeader: %v", err)
+			}
+
+			if header.Version != Version1 || header.Local != test.local {
+				t.Fatalf("got version %s local %t, want %s local %t", header.Version, header.Local, Version1, test.local)
+			}
+
+			if !test.local {
+				if header.Source.String() != test.source || header.Destination.String() != test.destination {
+					t.Errorf("got %s -> %s, want %s -> %s", header.Source, header.Destination, test.source, test.destination)
+				}
+			}
+
+			rest, _ := io.ReadAll(reader)
+			if string(rest) != "payload" {
+				t.Errorf("data after the header = %q, want %q", rest, "payload")
+			}
+		})
+	}
+}
+
+func TestReadHeaderV1Malformed(t *testing.T) {
+	inputs := []string{
+		"PROXY TCP4 192.0.2.1 198.51.100.1 56324\r\n",
+		"PROXY TCP4 not-an-ip 198.51.100.1 56324 443\r\n",
+		"PROXY TCP4 192.0.2.1 198.51.100.1 56324 70000\r\n",
+		"PROXY SCTP 192.0.2.1 198.51.100.1 56324 443\r\n",
+		"PROXY " + strings.Repeat("A", v1MaxLength) + "\r\n",
+	}
+
+	for _, input := range inputs {
+		if _, err := ReadHeader(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Errorf("ReadHeader(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestReadHeaderNoHeader(t *testing.T) {
+	for _, input := range []string{"GET / HTTP/1.1\r\n", "", "PRO"} {
+		_, err := ReadHeader(bufio.NewReader(strings.NewReader(input)))
+
+		if !errors.Is(err, ErrNoHeader) {
+			t.Errorf("ReadHeader(%q) error = %v, want ErrNoHeader", input, err)
+		}
+	}
+}
+
+func TestHeaderV2RoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      net.Addr
+		destination net.Addr
+	}{
+		{
+			name:        "tcp4",
+			source:      &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324},
+			destination: &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 443},
+		},
+		{
+			name:        "tcp6",
+			source:      &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324},
+			destination: &net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 443},
+		},
+		{
+			name:        "udp4",
+			source:      &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 5353},
+			destination: &net.UDPAddr{IP: net.ParseIP("198.51.100.1"), Port: 53},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+
+			if err := WriteHeader(&buffer, Version2, test.source, test.destination); err != nil {
+				t.Fatalf("WriteHeader: %v", err)
+			}
+
+			header, err := ReadHeader(bufio.NewReader(&buffer))
+			if err != nil {
+				t.Fatalf("ReadHeader: %v", err)
+			}
+
+			if header.Version != Version2 || header.Local {
+				t.Fatalf("got version %s local %t, want %s with addresses", header.Version, header.Local, Version2)
+			}
+
+			if header.Source.Network() != test.source.Network() {
+				t.Errorf("source network = %s, want %s", header.Source.Network(), test.source.Network())
+			}
+
+			if header.Source.String() != test.source.String() || header.Destination.String() != test.destination.String() {
+				t.Errorf("got %s -> %s, want %s -> %s", header.Source, header.Destination, test.source, test.destination)
+			}
+		})
+	}
+}
+
+func TestHeaderV2Local(t *testing.T) {
+	data, err := (&Header{Version: Version2, Local: true}).Format()
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+
+	header, err := ReadHeader(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+
+	if !header.Local {
+		t.Errorf("header.Local = false, want true")
+	}
+}
+
+func TestReadHeaderV2Truncated(t *testing.T) {
+	var buffer bytes.Buffer
+
+	source := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324}
+	destination := &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 443}
+
+	if err := WriteHeader(&buffer, Version2, source, destination); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+
+	data := buffer.Bytes()[:buffer.Len()-4]
+
+	if _, err := ReadHeader(bufio.NewReader(bytes.NewReader(data))); err == nil {
+		t.Errorf("ReadHeader of a truncated header succeeded, want an error")
+	}
+}
+
+func TestConnReadsHeader(t *testing.T) {
+	for _, version := range []string{Version1, Version2} {
+		t.Run(version, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+
+			conn := NewConn(server, time.Second)
+			defer conn.Close()
+
+			source := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324}
+			destination := &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 443}
+
+			go func() {
+				WriteHeader(client, version, source, destination)
+				client.Write([]byte("hello"))
+			}()
+
+			if got := conn.RemoteAddr().String(); got != source.String() {
+				t.Errorf("RemoteAddr = %s, want %s", got, source)
+			}
+
+			if got := conn.LocalAddr().String(); got != destination.String() {
+				t.Errorf("LocalAddr = %s, want %s", got, destination)
+			}
+
+			data := make([]byte, 5)
+
+			if _, err := io.ReadFull(conn, data); err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			if string(data) != "hello" {
+				t.Errorf("Read = %q, want %q", data, "hello")
+			}
+		})
+	}
+}
+
+func TestConnWithoutHeader(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	conn := NewConn(server, time.Second)
+	defer conn.Close()
+
+	go client.Write([]byte("GET / HTTP/1.1\r\n"))
+
+	if _, err := conn.Read(make([]byte, 16)); !errors.Is(err, ErrNoHeader) {
+		t.Errorf("Read error = %v, want ErrNoHeader", err)
+	}
+
+	if conn.RemoteAddr() != server.RemoteAddr() {
+		t.Errorf("RemoteAddr = %s, want the address of the connection", conn.RemoteAddr())
+	}
+}
+
+func TestConnHeaderTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	conn := NewConn(server, 50*time.Millisecond)
+	defer conn.Close()
+
+	if _, err := conn.Header(); err == nil {
+		t.Errorf("Header succeeded without data, want a timeout")
+	}
+}
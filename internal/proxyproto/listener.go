@@ -0,0 +1,106 @@
+package proxyproto
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"time"
+)
+
+const DefaultHeaderTimeout = 5 * time.Second
+
+type Listener struct {
+	net.Listener
+	headerTimeout time.Duration
+}
+
+func NewListener(listener net.Listener, headerTimeout time.Duration) *Listener {
+	return &Listener{
+		Listener:      listener,
+		headerTimeout: headerTimeout,
+	}
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConn(conn, l.headerTimeout), nil
+}
+
+// Conn reads the PROXY protocol header lazily, on the first call to Read,
+// RemoteAddr or LocalAddr, so a slow client never blocks the accept loop.
+type Conn struct {
+	net.Conn
+	reader        *bufio.Reader
+	headerTimeout time.Duration
+	header        *Header
+	headerErr     error
+	once          sync.Once
+}
+
+func NewConn(conn net.Conn, headerTimeout time.Duration) *Conn {
+	if headerTimeout <= 0 {
+		headerTimeout = DefaultHeaderTimeout
+	}
+
+	return &Conn{
+		Conn:          conn,
+		reader:        bufio.NewReader(conn),
+		headerTimeout: headerTimeout,
+	}
+}
+
+func (c *Conn) readHeader() {
+	c.once.Do(func() {
+		c.Conn.SetReadDeadline(time.Now().Add(c.headerTimeout))
+		c.header, c.headerErr = ReadHeader(c.reader)
+		c.Conn.SetReadDeadline(time.Time{})
+	})
+}
+
+func (c *Conn) Header() (*Header, error) {
+	c.readHeader()
+	return c.header, c.headerErr
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readHeader()
+
+	if c.headerErr != nil {
+		return 0, c.headerErr
+	}
+
+	return c.reader.Read(b)
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	c.readHeader()
+
+	if c.headerErr != nil || c.header.Local {
+		return c.Conn.RemoteAddr()
+	}
+
+	return c.header.Source
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	c.readHeader()
+
+	if c.headerErr != nil || c.header.Local {
+		return c.Conn.LocalAddr()
+	}
+
+	return c.header.Destination
+}
+
+func (c *Conn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+
+	return c.Conn.Close()
+}
@@ -0,0 +1,206 @@
+package ratelimit
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultIdleTimeout = 5 * time.Minute
+	DefaultMaxKeys     = 100000
+
+	// snapshotBuckets caps the buckets listed in a snapshot, so the admin API
+	// never copies the whole table while holding the lock
+	snapshotBuckets = 100
+)
+
+type Config struct {
+	Rate        float64
+	Burst       int
+	Key         string
+	IdleTimeout time.Duration
+	MaxKeys     int
+}
+
+type bucket struct {
+	key      string
+	tokens   float64
+	lastSeen time.Time
+}
+
+type BucketSnapshot struct {
+	Key      string    `json:"key"`
+	Tokens   float64   `json:"tokens"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type Snapshot struct {
+	Rate    float64          `json:"rate"`
+	Burst   int              `json:"burst"`
+	Key     string           `json:"key"`
+	Keys    int              `json:"keys"`
+	Allowed uint64           `json:"allowed"`
+	Limited uint64           `json:"limited"`
+	Buckets []BucketSnapshot `json:"buckets"`
+}
+
+// Limiter keeps a token bucket per key. Buckets are kept in a list ordered by
+// last use, so the least recently used one is evicted in constant time when
+// MaxKeys is reached and idle buckets are found at the back.
+type Limiter struct {
+	config      *Config
+	buckets     map[string]*list.Element
+	recent      *list.List
+	allowed     uint64
+	limited     uint64
+	mutex       sync.Mutex
+	stopChannel chan struct{}
+	stopOnce    sync.Once
+}
+
+func NewLimiter(config *Config) *Limiter {
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = DefaultIdleTimeout
+	}
+
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = DefaultMaxKeys
+	}
+
+	if config.Burst <= 0 {
+		config.Burst = int(math.Max(1, math.Ceil(config.Rate)))
+	}
+
+	limiter := &Limiter{
+		config:      config,
+		buckets:     make(map[string]*list.Element),
+		recent:      list.New(),
+		stopChannel: make(chan struct{}),
+	}
+
+	go limiter.evictionLoop()
+
+	return limiter
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens = math.Min(float64(l.config.Burst), b.tokens+elapsed*l.config.Rate)
+	b.lastSeen = now
+}
+
+// Allow takes a token from the bucket of key. When the bucket is empty it
+// returns false together with the time until the next token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	element, exists := l.buckets[key]
+
+	if exists {
+		l.recent.MoveToFront(element)
+	} else {
+		if len(l.buckets) >= l.config.MaxKeys {
+			l.evictOldest()
+		}
+
+		element = l.recent.PushFront(&bucket{key: key, tokens: float64(l.config.Burst), lastSeen: now})
+		l.buckets[key] = element
+	}
+
+	b := element.Value.(*bucket)
+
+	l.refill(b, now)
+
+	if b.tokens >= 1 {
+		b.tokens -= 1
+		l.allowed += 1
+		return true, 0
+	}
+
+	l.limited += 1
+
+	missing := 1 - b.tokens
+	retryAfter := time.Duration(missing / l.config.Rate * float64(time.Second))
+
+	return false, retryAfter
+}
+
+func (l *Limiter) remove(element *list.Element) {
+	l.recent.Remove(element)
+	delete(l.buckets, element.Value.(*bucket).key)
+}
+
+func (l *Limiter) evictOldest() {
+	if oldest := l.recent.Back(); oldest != nil {
+		l.remove(oldest)
+	}
+}
+
+func (l *Limiter) evictIdle() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+
+	for oldest := l.recent.Back(); oldest != nil; oldest = l.recent.Back() {
+		if now.Sub(oldest.Value.(*bucket).lastSeen) < l.config.IdleTimeout {
+			return
+		}
+
+		l.remove(oldest)
+	}
+}
+
+func (l *Limiter) evictionLoop() {
+	ticker := time.NewTicker(l.config.IdleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.evictIdle()
+		case <-l.stopChannel:
+			return
+		}
+	}
+}
+
+func (l *Limiter) Stop() {
+	l.stopOnce.Do(func() {
+		close(l.stopChannel)
+	})
+}
+
+func (l *Limiter) Snapshot() *Snapshot {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+
+	snapshot := &Snapshot{
+		Rate:    l.config.Rate,
+		Burst:   l.config.Burst,
+		Key:     l.config.Key,
+		Keys:    len(l.buckets),
+		Allowed: l.allowed,
+		Limited: l.limited,
+		Buckets: make([]BucketSnapshot, 0, min(len(l.buckets), snapshotBuckets)),
+	}
+
+	for element := l.recent.Front(); element != nil && len(snapshot.Buckets) < snapshotBuckets; element = element.Next() {
+		b := element.Value.(*bucket)
+		elapsed := now.Sub(b.lastSeen).Seconds()
+
+		snapshot.Buckets = append(snapshot.Buckets, BucketSnapshot{
+			Key:      b.key,
+			Tokens:   math.Min(float64(l.config.Burst), b.tokens+elapsed*l.config.Rate),
+			LastSeen: b.lastSeen,
+		})
+	}
+
+	return snapshot
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/franciscodelahoz/load-balancer/internal/clientip"
+	"github.com/franciscodelahoz/load-balancer/internal/router"
+)
+
+const (
+	KeyClientIP     = "client_ip"
+	KeyRoute        = "route"
+	KeyHeaderPrefix = "header:"
+)
+
+type KeyFunc func(r *http.Request) string
+
+func NewKeyFunc(key string) (KeyFunc, error) {
+	switch {
+	case key == "" || key == KeyClientIP:
+		return clientip.ClientIP, nil
+	case key == KeyRoute:
+		return func(r *http.Request) string {
+			return router.RouteName(r)
+		}, nil
+	case strings.HasPrefix(key, KeyHeaderPrefix):
+		header := strings.TrimPrefix(key, KeyHeaderPrefix)
+
+		if header == "" {
+			return nil, fmt.Errorf("missing header name in rate limit key: %s", key)
+		}
+
+		return func(r *http.Request) string {
+			// Requests without the header share the limit of their client IP
+			if value := r.Header.Get(header); value != "" {
+				return value
+			}
+
+			return clientip.ClientIP(r)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit key: %s", key)
+	}
+}
+
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	keyFunc, err := NewKeyFunc(l.config.Key)
+
+	if err != nil {
+		keyFunc = clientip.ClientIP
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, retryAfter := l.Allow(keyFunc(r))
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
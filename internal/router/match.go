@@ -0,0 +1,140 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/franciscodelahoz/load-balancer/internal/clientip"
+)
+
+// Match holds the conditions of a route; all of them must hold. For cookies
+// and query parameters an empty value only requires them to be present.
+type Match struct {
+	Host         string
+	PathPrefix   string
+	PathRegex    *regexp.Regexp
+	Methods      []string
+	Headers      map[string]string
+	HeaderRegex  map[string]*regexp.Regexp
+	Cookies      map[string]string
+	QueryParams  map[string]string
+	SourceRanges []*net.IPNet
+}
+
+func requestHost(r *http.Request) string {
+	host := r.Host
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(host)
+}
+
+func (m *Match) matchesHost(r *http.Request) bool {
+	if m.Host == "" {
+		return true
+	}
+
+	host := requestHost(r)
+	pattern := strings.ToLower(m.Host)
+
+	// "*.example.com" matches any direct subdomain of example.com, the same
+	// as a wildcard server name in SNI routing
+	if parent, ok := strings.CutPrefix(pattern, "*."); ok {
+		label, hostParent, found := strings.Cut(host, ".")
+		return found && label != "" && hostParent == parent
+	}
+
+	return host == pattern
+}
+
+func (m *Match) matchesMethod(r *http.Request) bool {
+	if len(m.Methods) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(m.Methods, func(method string) bool {
+		return strings.EqualFold(method, r.Method)
+	})
+}
+
+func (m *Match) matchesHeaders(r *http.Request) bool {
+	for name, value := range m.Headers {
+		if r.Header.Get(name) != value {
+			return false
+		}
+	}
+
+	for name, pattern := range m.HeaderRegex {
+		if !pattern.MatchString(r.Header.Get(name)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Match) matchesCookies(r *http.Request) bool {
+	for name, value := range m.Cookies {
+		cookie, err := r.Cookie(name)
+
+		if err != nil || (value != "" && cookie.Value != value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Match) matchesQueryParams(r *http.Request) bool {
+	if len(m.QueryParams) == 0 {
+		return true
+	}
+
+	query := r.URL.Query()
+
+	for name, value := range m.QueryParams {
+		if !query.Has(name) || (value != "" && query.Get(name) != value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesSource checks the client address, as resolved from trusted proxies.
+func (m *Match) matchesSource(r *http.Request) bool {
+	if len(m.SourceRanges) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(clientip.ClientIP(r))
+
+	if ip == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(m.SourceRanges, func(network *net.IPNet) bool {
+		return network.Contains(ip)
+	})
+}
+
+func (m *Match) Matches(r *http.Request) bool {
+	if !m.matchesHost(r) || !m.matchesMethod(r) {
+		return false
+	}
+
+	if m.PathPrefix != "" && !strings.HasPrefix(r.URL.Path, m.PathPrefix) {
+		return false
+	}
+
+	if m.PathRegex != nil && !m.PathRegex.MatchString(r.URL.Path) {
+		return false
+	}
+
+	return m.matchesHeaders(r) && m.matchesCookies(r) && m.matchesQueryParams(r) && m.matchesSource(r)
+}
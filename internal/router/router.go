@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type routeNameKey struct{}
+
+type Route struct {
+	Name    string
+	Match   *Match
+	Handler http.Handler
+}
+
+type Router struct {
+	routes         []*Route
+	defaultHandler http.Handler
+	mutex          sync.RWMutex
+}
+
+func NewRouter(defaultHandler http.Handler) *Router {
+	return &Router{
+		routes:         make([]*Route, 0),
+		defaultHandler: defaultHandler,
+	}
+}
+
+func (rt *Router) AddRoute(name string, match *Match, handler http.Handler) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	rt.routes = append(rt.routes, &Route{
+		Name:    name,
+		Match:   match,
+		Handler: handler,
+	})
+}
+
+func (rt *Router) findRoute(r *http.Request) *Route {
+	rt.mutex.RLock()
+	defer rt.mutex.RUnlock()
+
+	for _, route := range rt.routes {
+		if route.Match.Matches(r) {
+			return route
+		}
+	}
+
+	return nil
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := rt.findRoute(r)
+
+	if route != nil {
+		ctx := context.WithValue(r.Context(), routeNameKey{}, route.Name)
+		route.Handler.ServeHTTP(w, r.WithContext(ctx))
+		return
+	}
+
+	if rt.defaultHandler == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	rt.defaultHandler.ServeHTTP(w, r)
+}
+
+func RouteName(r *http.Request) string {
+	if name, ok := r.Context().Value(routeNameKey{}).(string); ok {
+		return name
+	}
+
+	return ""
+}
@@ -0,0 +1,222 @@
+package split
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+type Destination struct {
+	Pool   string
+	Weight uint64
+}
+
+type Config struct {
+	Name         string
+	Destinations []Destination
+
+	// HashHeader or HashCookie, when set, pin each user to one destination
+	// by hashing the value. Requests without it are spread at random.
+	HashHeader string
+	HashCookie string
+}
+
+type DestinationSnapshot struct {
+	Pool     string  `json:"pool"`
+	Weight   uint64  `json:"weight"`
+	Percent  float64 `json:"percent"`
+	Requests uint64  `json:"requests"`
+}
+
+type Snapshot struct {
+	HashHeader   string                `json:"hash_header,omitempty"`
+	HashCookie   string                `json:"hash_cookie,omitempty"`
+	Destinations []DestinationSnapshot `json:"destinations"`
+}
+
+type destination struct {
+	pool     string
+	weight   uint64
+	handler  http.Handler
+	requests uint64
+}
+
+// Split sends each request to one of several pools in proportion to their
+// weights. Weights can be changed at runtime without touching the weights of
+// the backends inside the pools.
+type Split struct {
+	config       Config
+	destinations []*destination
+	totalWeight  uint64
+	mutex        sync.RWMutex
+}
+
+// NewSplit creates a split serving each destination with the handler of its
+// pool.
+func NewSplit(config Config, handlers map[string]http.Handler) (*Split, error) {
+	s := &Split{
+		config:       config,
+		destinations: make([]*destination, 0, len(config.Destinations)),
+	}
+
+	for _, configured := range config.Destinations {
+		handler, exists := handlers[configured.Pool]
+
+		if !exists {
+			return nil, fmt.Errorf("unknown pool: %s", configured.Pool)
+		}
+
+		s.destinations = append(s.destinations, &destination{
+			pool:    configured.Pool,
+			weight:  configured.Weight,
+			handler: handler,
+		})
+
+		s.totalWeight += configured.Weight
+	}
+
+	if s.totalWeight == 0 {
+		return nil, fmt.Errorf("split %s has no destination with a weight", config.Name)
+	}
+
+	return s, nil
+}
+
+// SetWeights replaces the weights of the destinations. Every destination must
+// be given a weight, so a typo or a partial update cannot silently drain a
+// pool. The change is rejected when it names an unknown pool, leaves a pool
+// out or leaves no weight at all.
+func (s *Split) SetWeights(weights map[string]uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var totalWeight uint64
+
+	for _, d := range s.destinations {
+		weight, exists := weights[d.pool]
+
+		if !exists {
+			return fmt.Errorf("split %s needs a weight for pool %s", s.config.Name, d.pool)
+		}
+
+		totalWeight += weight
+	}
+
+	if len(weights) != len(s.destinations) {
+		for pool := range weights {
+			if !slices.ContainsFunc(s.destinations, func(d *destination) bool { return d.pool == pool }) {
+				return fmt.Errorf("split %s has no pool %s", s.config.Name, pool)
+			}
+		}
+	}
+
+	if totalWeight == 0 {
+		return fmt.Errorf("split %s needs a destination with a weight", s.config.Name)
+	}
+
+	for _, d := range s.destinations {
+		d.weight = weights[d.pool]
+	}
+
+	s.totalWeight = totalWeight
+
+	log.Printf("🔀 Split %s weights updated: %v", s.config.Name, weights)
+
+	return nil
+}
+
+// hashKey returns the value users are pinned by, or "" when the request has
+// none.
+func (s *Split) hashKey(r *http.Request) string {
+	if s.config.HashHeader != "" {
+		if value := r.Header.Get(s.config.HashHeader); value != "" {
+			return value
+		}
+	}
+
+	if s.config.HashCookie != "" {
+		if cookie, err := r.Cookie(s.config.HashCookie); err == nil && cookie.Value != "" {
+			return cookie.Value
+		}
+	}
+
+	return ""
+}
+
+// point returns the position of the request in [0, 1). Pinned users always
+// get the same position, so as the weights move they only change sides when
+// the boundary passes over them.
+func (s *Split) point(r *http.Request) float64 {
+	key := s.hashKey(r)
+
+	if key == "" {
+		return rand.Float64()
+	}
+
+	// The high bits of short keys are poorly mixed by cheaper hashes
+	sum := sha256.Sum256([]byte(key))
+
+	return float64(binary.BigEndian.Uint64(sum[:8])) / (math.MaxUint64 + 1.0)
+}
+
+func (s *Split) pick(r *http.Request) *destination {
+	point := s.point(r)
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	target := point * float64(s.totalWeight)
+	var selected *destination
+
+	for _, d := range s.destinations {
+		if d.weight == 0 {
+			continue
+		}
+
+		selected = d
+
+		if target < float64(d.weight) {
+			break
+		}
+
+		target -= float64(d.weight)
+	}
+
+	return selected
+}
+
+func (s *Split) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	selected := s.pick(r)
+	atomic.AddUint64(&selected.requests, 1)
+
+	selected.handler.ServeHTTP(w, r)
+}
+
+func (s *Split) Snapshot() *Snapshot {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	snapshot := &Snapshot{
+		HashHeader:   s.config.HashHeader,
+		HashCookie:   s.config.HashCookie,
+		Destinations: make([]DestinationSnapshot, 0, len(s.destinations)),
+	}
+
+	for _, d := range s.destinations {
+		snapshot.Destinations = append(snapshot.Destinations, DestinationSnapshot{
+			Pool:     d.pool,
+			Weight:   d.weight,
+			Percent:  float64(d.weight) / float64(s.totalWeight) * 100,
+			Requests: atomic.LoadUint64(&d.requests),
+		})
+	}
+
+	return snapshot
+}
@@ -0,0 +1,137 @@
+package strategies
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+)
+
+const DefaultOverprovisioningFactor = 1.4
+
+// PriorityStrategy sends requests to the lowest priority level that is healthy
+// enough and lets the wrapped strategy choose among its backends. A level is
+// considered fully healthy while its healthy share of backends, multiplied by
+// the overprovisioning factor, reaches 100%. Below that, the missing share of
+// requests overflows to the next level, so traffic moves over gradually as
+// health degrades and moves back as the level recovers.
+type PriorityStrategy struct {
+	base                   loadbalancer.LoadBalancerStrategy
+	overprovisioningFactor float64
+}
+
+func NewPriorityStrategy(base loadbalancer.LoadBalancerStrategy, overprovisioningFactor float64) *PriorityStrategy {
+	if overprovisioningFactor <= 0 {
+		overprovisioningFactor = DefaultOverprovisioningFactor
+	}
+
+	return &PriorityStrategy{
+		base:                   base,
+		overprovisioningFactor: overprovisioningFactor,
+	}
+}
+
+// GetPriorityLevels returns the levels of the pool from the most preferred
+// one, with the share of requests each receives.
+func (ps *PriorityStrategy) GetPriorityLevels(pool *loadbalancer.ServerPool) []loadbalancer.PriorityLevel {
+	levelsByPriority := make(map[int]*loadbalancer.PriorityLevel)
+
+	for _, b := range pool.GetAllBackends() {
+		level, exists := levelsByPriority[b.Priority]
+
+		if !exists {
+			level = &loadbalancer.PriorityLevel{Priority: b.Priority}
+			levelsByPriority[b.Priority] = level
+		}
+
+		level.Total += 1
+
+		if b.IsAlive() {
+			level.Healthy += 1
+		}
+	}
+
+	levels := make([]loadbalancer.PriorityLevel, 0, len(levelsByPriority))
+
+	for _, level := range levelsByPriority {
+		levels = append(levels, *level)
+	}
+
+	slices.SortFunc(levels, func(a, b loadbalancer.PriorityLevel) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	})
+
+	assigned := 0.0
+
+	for i := range levels {
+		health := float64(levels[i].Healthy) / float64(levels[i].Total) * ps.overprovisioningFactor
+		levels[i].Load = max(0, min(1-assigned, health))
+		assigned += levels[i].Load
+	}
+
+	// When all levels together are not healthy enough, the load is spread
+	// over them in proportion to their health
+	if assigned > 0 && assigned < 1 {
+		for i := range levels {
+			levels[i].Load /= assigned
+		}
+	}
+
+	return levels
+}
+
+func (ps *PriorityStrategy) GetNextBackend(pool *loadbalancer.ServerPool, r *http.Request) *backend.Backend {
+	levels := ps.GetPriorityLevels(pool)
+
+	if len(levels) == 0 {
+		return nil
+	}
+
+	priority := levels[0].Priority
+
+	if len(levels) > 1 {
+		point := rand.Float64()
+
+		for _, level := range levels {
+			if level.Load == 0 {
+				continue
+			}
+
+			priority = level.Priority
+
+			if point < level.Load {
+				break
+			}
+
+			point -= level.Load
+		}
+	}
+
+	return ps.base.GetNextBackend(pool.Filter(func(b *backend.Backend) bool { return b.Priority == priority }), r)
+}
+
+func (ps *PriorityStrategy) GetStrategyName() string {
+	return fmt.Sprintf("%s (priority levels)", ps.base.GetStrategyName())
+}
+
+func (ps *PriorityStrategy) OnBackendAdded(backend *backend.Backend) {
+	if eventAware, ok := ps.base.(loadbalancer.BackendEventAware); ok {
+		eventAware.OnBackendAdded(backend)
+	}
+}
+
+func (ps *PriorityStrategy) OnBackendRemoved(backend *backend.Backend) {
+	if eventAware, ok := ps.base.(loadbalancer.BackendEventAware); ok {
+		eventAware.OnBackendRemoved(backend)
+	}
+}
+
+func (ps *PriorityStrategy) OnBackendWeightChanged(backend *backend.Backend, oldWeight, newWeight uint64) {
+	if eventAware, ok := ps.base.(loadbalancer.BackendEventAware); ok {
+		eventAware.OnBackendWeightChanged(backend, oldWeight, newWeight)
+	}
+}
@@ -0,0 +1,117 @@
+package strategies
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+
+	"github.com/franciscodelahoz/load-balancer/internal/backend"
+	"github.com/franciscodelahoz/load-balancer/internal/loadbalancer"
+)
+
+const (
+	DefaultZoneLabel          = "zone"
+	DefaultSpilloverThreshold = 0.7
+)
+
+type ZoneAwareConfig struct {
+	// Zone of the load balancer itself
+	Zone string
+
+	// Label holding the zone of each backend
+	Label string
+
+	// SpilloverThreshold is the share of the local zone's capacity, by
+	// weight, that must be healthy to keep every request in the zone
+	SpilloverThreshold float64
+}
+
+// ZoneAwareStrategy keeps requests in the load balancer's own zone while that
+// zone is healthy enough, and lets the wrapped strategy choose among the
+// backends of the selected side. Below the threshold, traffic spills over to
+// the other zones in proportion to the capacity the local zone has lost.
+type ZoneAwareStrategy struct {
+	base   loadbalancer.LoadBalancerStrategy
+	config ZoneAwareConfig
+}
+
+func NewZoneAwareStrategy(base loadbalancer.LoadBalancerStrategy, config ZoneAwareConfig) *ZoneAwareStrategy {
+	if config.Label == "" {
+		config.Label = DefaultZoneLabel
+	}
+
+	if config.SpilloverThreshold <= 0 {
+		config.SpilloverThreshold = DefaultSpilloverThreshold
+	}
+
+	return &ZoneAwareStrategy{
+		base:   base,
+		config: config,
+	}
+}
+
+func (zas *ZoneAwareStrategy) isLocal(b *backend.Backend) bool {
+	return b.GetLabel(zas.config.Label) == zas.config.Zone
+}
+
+// localShare returns the share of requests to keep in the local zone.
+func (zas *ZoneAwareStrategy) localShare(pool *loadbalancer.ServerPool) float64 {
+	var localWeight, localHealthyWeight uint64
+	remoteHealthy := false
+
+	for _, b := range pool.GetAllBackends() {
+		local := zas.isLocal(b)
+		alive := b.IsAlive()
+
+		if local {
+			localWeight += b.GetWeight()
+
+			if alive {
+				localHealthyWeight += b.GetWeight()
+			}
+		} else if alive {
+			remoteHealthy = true
+		}
+	}
+
+	if localHealthyWeight == 0 {
+		return 0
+	}
+
+	if !remoteHealthy {
+		return 1
+	}
+
+	healthyRatio := float64(localHealthyWeight) / float64(localWeight)
+
+	return min(1, healthyRatio/zas.config.SpilloverThreshold)
+}
+
+func (zas *ZoneAwareStrategy) GetNextBackend(pool *loadbalancer.ServerPool, r *http.Request) *backend.Backend {
+	share := zas.localShare(pool)
+	local := share == 1 || rand.Float64() < share
+
+	return zas.base.GetNextBackend(pool.Filter(func(b *backend.Backend) bool { return zas.isLocal(b) == local }), r)
+}
+
+func (zas *ZoneAwareStrategy) GetStrategyName() string {
+	return fmt.Sprintf("%s (zone aware: %s)", zas.base.GetStrategyName(), zas.config.Zone)
+}
+
+func (zas *ZoneAwareStrategy) OnBackendAdded(backend *backend.Backend) {
+	if eventAware, ok := zas.base.(loadbalancer.BackendEventAware); ok {
+		eventAware.OnBackendAdded(backend)
+	}
+}
+
+func (zas *ZoneAwareStrategy) OnBackendRemoved(backend *backend.Backend) {
+	if eventAware, ok := zas.base.(loadbalancer.BackendEventAware); ok {
+		eventAware.OnBackendRemoved(backend)
+	}
+}
+
+func (zas *ZoneAwareStrategy) OnBackendWeightChanged(backend *backend.Backend, oldWeight, newWeight uint64) {
+	if eventAware, ok := zas.base.(loadbalancer.BackendEventAware); ok {
+		eventAware.OnBackendWeightChanged(backend, oldWeight, newWeight)
+	}
+}